@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -12,7 +13,9 @@ import (
 	"time"
 
 	"omgtant/claustroboard/shared/config"
+	"omgtant/claustroboard/shared/models"
 	"omgtant/claustroboard/web"
+	"omgtant/claustroboard/web/routers"
 )
 
 var (
@@ -25,23 +28,58 @@ func Start() {
 	dbAddr := config.Get().DATABASE_CONNECTION_STRING
 	addr := config.Get().APP_ADDRESS
 
-	if dbAddr == "" {
-		panic("DATABASE_CONNECTION_STRING environment variable is not set")
-	}
 	if addr == "" {
 		panic("APP_ADDRESS environment variable is not set")
 	}
+	idleTimeout := parseDuration(config.Get().BOARD_IDLE_TIMEOUT, 10*time.Minute)
+	retention := parseDuration(config.Get().BOARD_RETENTION, time.Hour)
+
+	// storage
+
+	if dbAddr == "" {
+		fmt.Println("DATABASE_CONNECTION_STRING is not set, games will not survive a restart")
+	}
+	repo, err := models.OpenRepository(dbAddr)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to open the database: %v", err))
+	}
+	defer repo.Close()
+
+	restored, err := models.UseRepository(repo)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to load stored games: %v", err))
+	}
+	fmt.Printf("Restored %d games\n", restored)
+	models.HandleCrashes(routers.GameCrashed)
+	routers.ResumeGames()
+
+	// board lifecycle
+
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+
+	go models.RunJanitor(ctx, models.JanitorConfig{
+		IdleTimeout: idleTimeout,
+		Retention:   retention,
+		Connected:   routers.HasClients,
+		Evicted:     routers.CloseGame,
+	})
 
 	// http
 
 	r := web.GetRouter(ProjectRoot)
 
+	// Cancelled on shutdown, to end the requests that stream, e.g. the lobby list
+	requestsCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+
 	s := &http.Server{
 		Addr:         addr,
 		Handler:      r,
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  15 * time.Second,
+		BaseContext:  func(net.Listener) context.Context { return requestsCtx },
 	}
 
 	sigint := make(chan os.Signal, 1)
@@ -66,6 +104,11 @@ func Start() {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
+		// Websockets are hijacked, the server does not know about them
+		routers.Shutdown(ctx)
+		fmt.Printf("Saved %d games\n", models.Shutdown())
+
+		cancelRequests()
 		if err := s.Shutdown(ctx); err != nil {
 			fmt.Println(err)
 
@@ -75,3 +118,14 @@ func Start() {
 		}
 	}
 }
+
+func parseDuration(s string, fallback time.Duration) time.Duration {
+	if s == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		panic(fmt.Sprintf("invalid duration %q: %v", s, err))
+	}
+	return d
+}
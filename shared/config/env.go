@@ -25,6 +25,9 @@ func (e *EnvLoader) Load(c *config) error {
 	c.APP_ADDRESS = envFile["APP_ADDRESS"]
 	c.ENVIRONMENT = envFile["ENVIROMENT"]
 	c.DATABASE_CONNECTION_STRING = envFile["DATABASE_CONNECTION_STRING"]
+	c.BOARD_IDLE_TIMEOUT = envFile["BOARD_IDLE_TIMEOUT"]
+	c.BOARD_RETENTION = envFile["BOARD_RETENTION"]
+	c.SLOW_CLIENT_POLICY = envFile["SLOW_CLIENT_POLICY"]
 
 	return nil
 }
@@ -6,6 +6,9 @@ type config struct {
 	APP_ADDRESS                string
 	ENVIRONMENT                string
 	DATABASE_CONNECTION_STRING string
+	BOARD_IDLE_TIMEOUT         string // Optional, e.g. "10m"
+	BOARD_RETENTION            string // Optional, e.g. "1h"
+	SLOW_CLIENT_POLICY         string // Optional, "resync" or "disconnect"
 }
 
 var configInstance *config
@@ -45,6 +45,10 @@ func (sl *SecretLoader) Load(c *config) error {
 		return value
 	}
 
+	getOptionalSecret := func(key string) string {
+		return sl.secrets[key]
+	}
+
 	// getBoolSecret := func(key string) bool {
 	// 	strValue := getSecret(key)
 	// 	if loadErr != nil {
@@ -61,6 +65,9 @@ func (sl *SecretLoader) Load(c *config) error {
 	c.APP_ADDRESS = getSecret("app_address")
 	c.ENVIRONMENT = getSecret("environment")
 	c.DATABASE_CONNECTION_STRING = getSecret("database_connection_string")
+	c.BOARD_IDLE_TIMEOUT = getOptionalSecret("board_idle_timeout")
+	c.BOARD_RETENTION = getOptionalSecret("board_retention")
+	c.SLOW_CLIENT_POLICY = getOptionalSecret("slow_client_policy")
 
 	return loadErr
 }
@@ -1,49 +1,73 @@
 package dtos
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"time"
+)
 
 type Board struct {
-    Palette Palette       `json:"palette"`
-    Width   uint16        `json:"width"`
-    Height  uint16        `json:"height"`
-    Tiles   [][]BoardTile `json:"board"`
-    Players []Player      `json:"players"`
+	Palette      Palette       `json:"palette"`
+	Width        uint16        `json:"width"`
+	Height       uint16        `json:"height"`
+	Tiles        [][]BoardTile `json:"board"`
+	Players      []Player      `json:"players"`
+	Turn         uint32        `json:"turn"`                   // CheckTurn of the last applied delta
+	Current      int           `json:"current"`                // Index of the player whose turn it is
+	Round        uint32        `json:"round"`                  // Number of times the turn order wrapped around
+	Seed         int64         `json:"seed"`                   // Replays the same board and start layout with the same deck and players
+	TurnDeadline *time.Time    `json:"turnDeadline,omitempty"` // When the current player runs out of time
+	Hash         string        `json:"hash"`                   // See engine.Game.StateHash
 }
 
 type GameConfig struct {
-    Version    int          `json:"version"`
-    Width      int          `json:"width"`
-    Height     int          `json:"height"`
-    MaxPlayers int          `json:"maxPlayers"`
-    Deck       []TileConfig `json:"deck"`
+	Version    int          `json:"version"`
+	Width      int          `json:"width"`
+	Height     int          `json:"height"`
+	MaxPlayers int          `json:"maxPlayers"`
+	Deck       []TileConfig `json:"deck"`
+	Public     bool         `json:"public,omitempty"` // Listed in the lobby browser
+	Seed       *int64       `json:"seed,omitempty"`   // Random when omitted
+	// Seconds each player has to move, unlimited when 0
+	TurnTimeLimit int           `json:"turnTimeLimit,omitempty"`
+	TimeoutPolicy TimeoutPolicy `json:"timeoutPolicy,omitempty"` // TimeoutRandom when omitted
 }
 
+// TimeoutPolicy is what happens when the current player runs out of time.
+type TimeoutPolicy string
+
+const (
+	TimeoutRandom    TimeoutPolicy = "random"    // A random legal move is played for them
+	TimeoutSkip      TimeoutPolicy = "skip"      // The turn passes without moving
+	TimeoutEliminate TimeoutPolicy = "eliminate" // They lose, as if they could not move
+)
+
 type Count int
+
 const UnspecifiedCount Count = -1
 
 // makes encoding/json treat -1 as "empty" for `omitempty`.
 func (c Count) IsZero() bool { return c == UnspecifiedCount }
 
 type TileConfig struct {
-    Tile  BoardTile `json:"tile"`
-    Count Count     `json:"count,omitempty"`
+	Tile  BoardTile `json:"tile"`
+	Count Count     `json:"count,omitempty"`
 }
 
 // set tc.Count = UnspecifiedCount if "null"
 func (tc *TileConfig) UnmarshalJSON(b []byte) error {
-    type wire struct {
-        Tile  BoardTile `json:"tile"`
-        Count *int      `json:"count"`
-    }
-    var w wire
-    if err := json.Unmarshal(b, &w); err != nil {
-        return err
-    }
-    tc.Tile = w.Tile
-    if w.Count == nil {
-        tc.Count = UnspecifiedCount
-    } else {
-        tc.Count = Count(*w.Count)
-    }
-    return nil
-}
\ No newline at end of file
+	type wire struct {
+		Tile  BoardTile `json:"tile"`
+		Count *int      `json:"count"`
+	}
+	var w wire
+	if err := json.Unmarshal(b, &w); err != nil {
+		return err
+	}
+	tc.Tile = w.Tile
+	if w.Count == nil {
+		tc.Count = UnspecifiedCount
+	} else {
+		tc.Count = Count(*w.Count)
+	}
+	return nil
+}
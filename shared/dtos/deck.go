@@ -0,0 +1,10 @@
+package dtos
+
+// DeckPreset is a named config games can be created from. Builtin presets
+// ship with the server, the others were saved by players.
+type DeckPreset struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Builtin     bool       `json:"builtin"`
+	Config      GameConfig `json:"config"`
+}
@@ -3,12 +3,31 @@ package dtos
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"omgtant/claustroboard/shared/valueobjects"
 )
 
+// Delta is one player's turn. Steps holds the whole sequence of choices
+// (`[(x1,y1),(x2,y2),z3,...]`); Move is its first step, kept for clients that
+// only send or read a single destination.
 type Delta struct {
-	Turn  uint32 	`json:"turn"`
-	Move  Move 		`json:"move"`
+	Turn    uint32        `json:"turn"`
+	Move    *Move         `json:"move,omitempty"`
+	Steps   []Move        `json:"delta,omitempty"`
+	Timeout TimeoutPolicy `json:"timeout,omitempty"` // Set when the player ran out of time, there are no steps unless a random move was played
+	Forfeit *int          `json:"forfeit,omitempty"` // Index of the player eliminated out of turn, e.g. for leaving, there are no steps
+	Hash    string        `json:"hash,omitempty"`    // State hash once the delta is applied, see engine.Game.StateHash
+}
+
+// Sequence returns the choices of the delta in order.
+func (d Delta) Sequence() []Move {
+	if len(d.Steps) > 0 {
+		return d.Steps
+	}
+	if d.Move != nil {
+		return []Move{*d.Move}
+	}
+	return nil
 }
 
 type moveType uint8
@@ -24,6 +43,14 @@ type Move struct {
 	i     int64
 }
 
+func PointMove(p valueobjects.Point) Move {
+	return Move{tag: moveTypePoint, point: p}
+}
+
+func IntMove(i int64) Move {
+	return Move{tag: moveTypeInt, i: i}
+}
+
 func (m Move) MarshalJSON() ([]byte, error) {
 	switch m.tag {
 	case moveTypePoint:
@@ -58,3 +85,17 @@ func (m *Move) GetPoint() (p valueobjects.Point, err error) {
 	}
 	return p, errors.New("not a point")
 }
+
+func (m *Move) GetInt() (i int64, err error) {
+	if m.tag == moveTypeInt {
+		return m.i, nil
+	}
+	return i, errors.New("not an int")
+}
+
+func (m Move) String() string {
+	if m.tag == moveTypeInt {
+		return fmt.Sprintf("%d", m.i)
+	}
+	return m.point.String()
+}
@@ -0,0 +1,35 @@
+package dtos
+
+import "omgtant/claustroboard/shared/enums"
+
+// Lobby is a public game waiting for players, as listed in the lobby browser.
+type Lobby struct {
+	Code       string        `json:"code"`
+	Players    []string      `json:"players"`
+	MaxPlayers int           `json:"maxPlayers"`
+	Width      uint16        `json:"width"`
+	Height     uint16        `json:"height"`
+	Deck       []DeckSummary `json:"deck"`
+}
+
+// DeckSummary counts the guaranteed tiles of a kind in a deck.
+// Random is set when the kind can also fill the rest of the board.
+type DeckSummary struct {
+	Name   enums.TileKindName `json:"tile_type"`
+	Count  int                `json:"count"`
+	Random bool               `json:"random,omitempty"`
+}
+
+type LobbyChangeType string
+
+const (
+	LobbyAdded   LobbyChangeType = "lobby-added"
+	LobbyChanged LobbyChangeType = "lobby-changed"
+	LobbyRemoved LobbyChangeType = "lobby-removed"
+)
+
+type LobbyChange struct {
+	Type  LobbyChangeType `json:"type"`
+	Code  string          `json:"code"`
+	Lobby *Lobby          `json:"lobby,omitempty"`
+}
@@ -5,6 +5,7 @@ import (
 )
 
 type Player struct {
-	Name string             `json:"nickname"`
-	Pos  valueobjects.Point `json:"position"`
+	Name   string             `json:"nickname"`
+	Pos    valueobjects.Point `json:"position"`
+	Active bool               `json:"active"`
 }
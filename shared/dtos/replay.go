@@ -0,0 +1,14 @@
+package dtos
+
+import "time"
+
+// Replay is the archive of a finished game: replaying Deltas in order
+// on Initial leads to the final board.
+type Replay struct {
+	Code       string      `json:"code"`
+	Config     GameConfig  `json:"config"`
+	Initial    *Board      `json:"initial"`
+	Deltas     []Delta     `json:"deltas"`
+	Result     *GameResult `json:"result"`
+	FinishedAt time.Time   `json:"finishedAt"`
+}
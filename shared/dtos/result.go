@@ -0,0 +1,10 @@
+package dtos
+
+type Placement struct {
+	Place int    `json:"place"`
+	Name  string `json:"nickname"`
+}
+
+type GameResult struct {
+	Placements []Placement `json:"placements"`
+}
@@ -0,0 +1,9 @@
+package dtos
+
+// Resync answers a `come-again` action. It holds either the deltas the client
+// missed, or the full board state when they cannot be replayed.
+type Resync struct {
+	Turn     uint32  `json:"turn"`
+	Deltas   []Delta `json:"deltas,omitempty"`
+	Snapshot *Board  `json:"snapshot,omitempty"`
+}
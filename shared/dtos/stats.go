@@ -0,0 +1,7 @@
+package dtos
+
+type BoardStats struct {
+	Live    int    `json:"live"`
+	Evicted uint64 `json:"evicted"`
+	Clients int    `json:"clients"` // Connected sockets
+}
@@ -15,26 +15,28 @@ type BoardTile struct {
 	Name  enums.TileKindName         `json:"tile_type"`
 	Color enums.TileColor            `json:"color,omitempty"`
 	Data  map[string]json.RawMessage `json:"data,omitempty"`
+	// Only set in board states, tiles are always dealt open
+	Closed bool `json:"closed,omitempty"`
 }
 
 func (bt *BoardTile) UnmarshalJSON(data []byte) error {
-    type Alias BoardTile
-    aux := &struct {
-        *Alias
-        Color *int `json:"color,omitempty"`
-    }{
-        Alias: (*Alias)(bt),
-    }
-
-    if err := json.Unmarshal(data, &aux); err != nil {
-        return err
-    }
-
-    if aux.Color == nil {
-        bt.Color = enums.UnspecifiedColor
-    } else {
-        bt.Color = enums.TileColor(*aux.Color)
-    }
-
-    return nil
-}
\ No newline at end of file
+	type Alias BoardTile
+	aux := &struct {
+		*Alias
+		Color *int `json:"color,omitempty"`
+	}{
+		Alias: (*Alias)(bt),
+	}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	if aux.Color == nil {
+		bt.Color = enums.UnspecifiedColor
+	} else {
+		bt.Color = enums.TileColor(*aux.Color)
+	}
+
+	return nil
+}
@@ -0,0 +1,331 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"omgtant/claustroboard/shared/dtos"
+	"slices"
+	"time"
+)
+
+// BotLevel is the difficulty of a bot player.
+type BotLevel string
+
+const (
+	BotRandom  BotLevel = "random"  // Any legal move
+	BotGreedy  BotLevel = "greedy"  // Best position right after its own move
+	BotMinimax BotLevel = "minimax" // Looks a few turns ahead, assuming everyone plays against it
+)
+
+var BotLevels = []BotLevel{BotRandom, BotGreedy, BotMinimax}
+
+const (
+	// How many games a bot may clone to try moves on, or mobilities it may count
+	// to score them, before it plays the best move found.
+	botNodeBudget = 2000
+	// Hard limit on the time a bot may think, in case the node budget takes far
+	// longer than expected. Bots only play differently from replays once it is hit.
+	botTimeLimit = 5 * time.Second
+)
+
+// Bot picks the moves of a player.
+type Bot interface {
+	// Play returns a delta for the current player of g, without changing g.
+	// It gives up searching for better moves once it spent botNodeBudget, or after
+	// botTimeLimit.
+	Play(g *Game) ([]dtos.Move, error)
+}
+
+// NewBot returns a bot of the given level, breaking ties with rng.
+func NewBot(level BotLevel, rng *rand.Rand) (Bot, error) {
+	switch level {
+	case BotRandom:
+		return randomBot{rng}, nil
+	case BotGreedy:
+		return greedyBot{rng}, nil
+	case BotMinimax:
+		return minimaxBot{rng: rng, depth: 3}, nil
+	}
+	return nil, fmt.Errorf("unknown bot level %q", level)
+}
+
+var (
+	errNoMoves     = errors.New("no legal move")
+	errBudgetSpent = errors.New("search budget spent")
+)
+
+type randomBot struct {
+	rng *rand.Rand
+}
+
+func (b randomBot) Play(g *Game) ([]dtos.Move, error) {
+	bud := newBudget()
+	deltas := g.boundedDeltas(bud)
+	if len(deltas) == 0 {
+		return nil, bud.noDeltaError()
+	}
+	return deltas[b.rng.Intn(len(deltas))], nil
+}
+
+type greedyBot struct {
+	rng *rand.Rand
+}
+
+func (b greedyBot) Play(g *Game) ([]dtos.Move, error) {
+	me := g.CurPlayer()
+	bud := newBudget()
+	deltas := g.boundedDeltas(bud)
+	if len(deltas) == 0 {
+		return nil, bud.noDeltaError()
+	}
+	best, _ := pickBest(b.rng, g, deltas, bud, func(next *Game) float64 {
+		return evaluate(next, me, bud)
+	})
+	return best, nil
+}
+
+// minimaxBot searches up to depth plies ahead with alpha-beta pruning, one ply
+// deeper at a time while its budget lasts. Opponents are assumed to minimize its score.
+type minimaxBot struct {
+	rng   *rand.Rand
+	depth int
+}
+
+func (b minimaxBot) Play(g *Game) ([]dtos.Move, error) {
+	me := g.CurPlayer()
+	bud := newBudget()
+	deltas := g.boundedDeltas(bud)
+	if len(deltas) == 0 {
+		return nil, bud.noDeltaError()
+	}
+
+	var best []dtos.Move
+	for depth := 1; depth <= b.depth; depth++ {
+		moves, complete := pickBest(b.rng, g, deltas, bud, func(next *Game) float64 {
+			return b.search(next, me, depth-1, math.Inf(-1), math.Inf(1), bud)
+		})
+		// A search cut short is only better than nothing
+		if complete || best == nil {
+			best = moves
+		}
+		if !complete {
+			break
+		}
+	}
+	return best, nil
+}
+
+func (b minimaxBot) search(g *Game, me, depth int, alpha, beta float64, bud *budget) float64 {
+	if depth <= 0 || g.Over || !g.IsActive[me] || bud.spent() {
+		return evaluate(g, me, bud)
+	}
+
+	maximizing := g.CurPlayer() == me
+	best := math.Inf(1)
+	if maximizing {
+		best = math.Inf(-1)
+	}
+	for _, d := range g.boundedDeltas(bud) {
+		if !bud.spend() {
+			break
+		}
+		next := g.Clone()
+		if _, err := next.apply(d); err != nil {
+			continue
+		}
+		v := b.search(next, me, depth-1, alpha, beta, bud)
+		if maximizing {
+			best = max(best, v)
+			alpha = max(alpha, v)
+		} else {
+			best = min(best, v)
+			beta = min(beta, v)
+		}
+		if alpha >= beta {
+			break
+		}
+	}
+	if math.IsInf(best, 0) {
+		return evaluate(g, me, bud)
+	}
+	return best
+}
+
+// pickBest plays every delta on a copy of g and returns the one scoring highest,
+// picking at random among equals. complete is false when bud ran out before
+// every delta was scored, the best of those that were is returned then.
+func pickBest(rng *rand.Rand, g *Game, deltas [][]dtos.Move, bud *budget, score func(next *Game) float64) (move []dtos.Move, complete bool) {
+	var best [][]dtos.Move
+	bestScore := math.Inf(-1)
+	complete = true
+	for _, d := range deltas {
+		if !bud.spend() {
+			complete = false
+			break
+		}
+		next := g.Clone()
+		if _, err := next.apply(d); err != nil {
+			continue
+		}
+		switch v := score(next); {
+		case v > bestScore:
+			best, bestScore = [][]dtos.Move{d}, v
+		case v == bestScore:
+			best = append(best, d)
+		}
+	}
+	if bud.spent() {
+		complete = false
+	}
+	if len(best) == 0 {
+		// Nothing could be scored, any delta will do
+		return deltas[rng.Intn(len(deltas))], complete
+	}
+	return best[rng.Intn(len(best))], complete
+}
+
+// budget bounds the search of a bot: every game cloned to try moves on and every
+// mobility counted to score them uses a node. Unlike nodes, which make the search
+// the same on every run, the deadline is only a safety net.
+type budget struct {
+	nodes    int
+	deadline time.Time
+}
+
+func newBudget() *budget {
+	return &budget{nodes: botNodeBudget, deadline: time.Now().Add(botTimeLimit)}
+}
+
+// spend accounts for one more game, and reports false when the budget ran out.
+// A nil budget never runs out.
+func (b *budget) spend() bool {
+	if b == nil {
+		return true
+	}
+	if b.spent() {
+		return false
+	}
+	b.nodes--
+	return true
+}
+
+// use accounts for n nodes of work that already happened.
+func (b *budget) use(n int) {
+	if b != nil {
+		b.nodes -= n
+	}
+}
+
+func (b *budget) spent() bool {
+	return b != nil && (b.nodes <= 0 || time.Now().After(b.deadline))
+}
+
+// noDeltaError explains why no delta was found within the budget.
+func (b *budget) noDeltaError() error {
+	if b.spent() {
+		return errBudgetSpent
+	}
+	return errNoMoves
+}
+
+// evaluate scores g from player me's point of view: its own mobility against
+// the average mobility of its opponents, or how late it lost once it did.
+// Every mobility counted uses a node of bud.
+func evaluate(g *Game, me int, bud *budget) float64 {
+	if !g.IsActive[me] {
+		return -1000 + float64(slices.Index(g.Eliminated, me))
+	}
+	if g.Over {
+		return 1000
+	}
+
+	opponents, theirs := 0, 0
+	for p := range g.Players {
+		if p != me && g.IsActive[p] {
+			opponents++
+			theirs += g.mobility(p)
+		}
+	}
+	bud.use(opponents + 1)
+	score := float64(g.mobility(me))
+	if opponents > 0 {
+		score -= float64(theirs) / float64(opponents)
+	}
+	return score
+}
+
+// mobility counts the tiles player p could walk to if it were their turn.
+func (g *Game) mobility(p int) int {
+	tile, err := g.getTileAt(g.Pos[p])
+	if err != nil {
+		return 0
+	}
+	return len(tile.AvailableMoves(g, p))
+}
+
+// LegalDeltas lists every complete delta the current player may play:
+// each legal walk destination followed by the choices its landing requires.
+func (g *Game) LegalDeltas() [][]dtos.Move {
+	return g.boundedDeltas(nil)
+}
+
+// boundedDeltas lists legal deltas like LegalDeltas until bud runs out.
+func (g *Game) boundedDeltas(bud *budget) [][]dtos.Move {
+	var deltas [][]dtos.Move
+	for _, dest := range g.LegalMoves() {
+		more, err := g.completeDelta([]dtos.Move{dtos.PointMove(dest)}, bud)
+		deltas = append(deltas, more...)
+		if errors.Is(err, errBudgetSpent) {
+			break
+		}
+	}
+	return deltas
+}
+
+// completeDelta extends steps with every sequence of choice indices that makes
+// a valid delta, until bud runs out.
+func (g *Game) completeDelta(steps []dtos.Move, bud *budget) ([][]dtos.Move, error) {
+	if !bud.spend() {
+		return nil, errBudgetSpent
+	}
+	_, err := g.Clone().apply(steps)
+	if err == nil {
+		return [][]dtos.Move{steps}, nil
+	}
+	if !errors.Is(err, errChoiceMissing) {
+		return nil, err
+	}
+
+	var deltas [][]dtos.Move
+	// No choice has more candidates than there are tiles
+	for i := range int64(g.Width) * int64(g.Height) {
+		more, err := g.completeDelta(append(slices.Clone(steps), dtos.IntMove(i)), bud)
+		if errors.Is(err, errChoiceOutOfRange) {
+			break
+		}
+		deltas = append(deltas, more...)
+		if errors.Is(err, errBudgetSpent) {
+			return deltas, err
+		}
+	}
+	return deltas, nil
+}
+
+// Clone returns a deep copy of the game, e.g. to try moves on.
+func (g *Game) Clone() *Game {
+	c := *g
+	c.Tiles = make([][]Tile, len(g.Tiles))
+	for y, row := range g.Tiles {
+		c.Tiles[y] = make([]Tile, len(row))
+		for x, tile := range row {
+			c.Tiles[y][x] = tile.CopyFor(tile.Pos)
+		}
+	}
+	c.Players = slices.Clone(g.Players)
+	c.Pos = slices.Clone(g.Pos)
+	c.IsActive = slices.Clone(g.IsActive)
+	c.Eliminated = slices.Clone(g.Eliminated)
+	return &c
+}
@@ -0,0 +1,162 @@
+package engine
+
+import (
+	"encoding/json"
+	"math/rand"
+	"omgtant/claustroboard/shared/dtos"
+	"omgtant/claustroboard/shared/valueobjects"
+	"testing"
+	"time"
+)
+
+func TestBotsPlayWholeGames(t *testing.T) {
+	cfg := testConfig(t, mixedDeck)
+	for _, level := range BotLevels {
+		t.Run(string(level), func(t *testing.T) {
+			for seed := int64(0); seed < 5; seed++ {
+				g, err := New(cfg, []string{"a", "b", "c"}, seed)
+				if err != nil {
+					t.Fatal(err)
+				}
+				bot, err := NewBot(level, rand.New(rand.NewSource(seed)))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				for turns := 0; !g.Over; turns++ {
+					if turns > 16 {
+						t.Fatal("game did not end")
+					}
+					moves, err := bot.Play(g)
+					if err != nil {
+						t.Fatalf("seed %d: %v", seed, err)
+					}
+					if _, err := g.Apply(moves); err != nil {
+						t.Fatalf("seed %d: bot played %v: %v", seed, moves, err)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestNewBotUnknownLevel(t *testing.T) {
+	if _, err := NewBot("godlike", rand.New(rand.NewSource(1))); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestLegalDeltasIncludeChoices(t *testing.T) {
+	g, err := scenario{
+		Board: []string{
+			"L1 T L1",
+			"L1 #L1 L1",
+		},
+		Players: []scenarioSeat{{At: "0,0"}, {At: "2,1"}},
+	}.game()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Walking down, or teleporting to (2,0) or (0,1)
+	want := [][]dtos.Move{
+		{dtos.PointMove(point(0, 1))},
+		{dtos.PointMove(point(1, 0)), dtos.IntMove(0)},
+		{dtos.PointMove(point(1, 0)), dtos.IntMove(1)},
+	}
+	got := g.LegalDeltas()
+	if len(got) != len(want) {
+		t.Fatalf("deltas %v, want %v", got, want)
+	}
+	for _, w := range want {
+		found := false
+		for _, d := range got {
+			if sameMoves(d, w) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("missing delta %v in %v", w, got)
+		}
+	}
+}
+
+func TestBotsAvoidDeadEnds(t *testing.T) {
+	// Walking left or right leads p0 into a dead end, only down keeps it moving
+	g, err := scenario{
+		Board: []string{
+			"L1 L1 L1 #L1",
+			"#L1 L1 #L1 #L1",
+			"#L1 L1 L1 L1",
+		},
+		Players: []scenarioSeat{{At: "1,0"}, {At: "3,2"}},
+	}.game()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, level := range []BotLevel{BotGreedy, BotMinimax} {
+		for seed := int64(0); seed < 10; seed++ {
+			bot, _ := NewBot(level, rand.New(rand.NewSource(seed)))
+			moves, err := bot.Play(g)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p, _ := moves[0].GetPoint(); p == point(2, 0) || p == point(0, 0) {
+				t.Errorf("%s bot walked into a dead end at %v", level, p)
+			}
+		}
+	}
+}
+
+func point(x, y uint16) valueobjects.Point {
+	return valueobjects.Point{X: x, Y: y}
+}
+
+func sameMoves(a, b []dtos.Move) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].String() != b[i].String() {
+			return false
+		}
+	}
+	return true
+}
+
+func TestMinimaxStaysWithinItsBudget(t *testing.T) {
+	var cfg dtos.GameConfig
+	deck := `[
+		{"tile": {"tile_type": "Wildcard"}, "count": null},
+		{"tile": {"tile_type": "Teleport"}, "count": 64}
+	]`
+	if err := json.Unmarshal([]byte(`{"width":16,"height":16,"deck":`+deck+`}`), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	g, err := New(cfg, []string{"a", "b", "c", "d", "e", "f", "g", "h"}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The node budget alone limits the search, so the same seed plays the same move
+	var played [][]dtos.Move
+	for range 2 {
+		bot, _ := NewBot(BotMinimax, rand.New(rand.NewSource(1)))
+		start := time.Now()
+		moves, err := bot.Play(g)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if elapsed := time.Since(start); elapsed >= botTimeLimit {
+			t.Errorf("minimax took %v, its node budget did not stop it", elapsed)
+		}
+		played = append(played, moves)
+	}
+	if !sameMoves(played[0], played[1]) {
+		t.Errorf("same seed played %v then %v", played[0], played[1])
+	}
+	if _, err := g.Apply(played[0]); err != nil {
+		t.Errorf("minimax played %v: %v", played[0], err)
+	}
+}
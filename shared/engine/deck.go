@@ -0,0 +1,96 @@
+package engine
+
+import (
+	"fmt"
+	"math/rand"
+	"omgtant/claustroboard/shared/dtos"
+	"omgtant/claustroboard/shared/enums"
+	"omgtant/claustroboard/shared/valueobjects"
+	"slices"
+)
+
+// deal lays a fresh set of tiles from deck.
+func (g *Game) deal(rng *rand.Rand, deck []dtos.TileConfig) error {
+	g.Tiles = make([][]Tile, g.Height)
+	for i := range g.Tiles {
+		g.Tiles[i] = make([]Tile, g.Width)
+	}
+
+	deck = slices.Clone(deck)
+	return g.fillUsingDeck(rng, &deck)
+}
+
+func (g *Game) fillUsingDeck(rng *rand.Rand, deck *[]dtos.TileConfig) error {
+	if deck == nil {
+		return fmt.Errorf("deck is nil")
+	}
+
+	boardSize := int(g.Width) * int(g.Height)
+
+	// 1) Gather guaranteed tiles (with defined count), repeat as needed.
+	guaranteed := make([]dtos.TileConfig, 0, boardSize)
+	for _, d := range *deck {
+		if d.Count <= 0 {
+			continue
+		}
+		for i := 0; i < int(d.Count); i++ {
+			guaranteed = append(guaranteed, d)
+		}
+	}
+
+	// 2) If not enough guaranteed, fill remaining with random tiles chosen from "choice" tiles (count == nil).
+	if len(guaranteed) < boardSize {
+		choices := make([]dtos.TileConfig, 0)
+		for _, d := range *deck {
+			if d.Count < 0 {
+				choices = append(choices, d)
+			}
+		}
+		if len(choices) == 0 {
+			return fmt.Errorf("deck underfills the board (%d guaranteed < %d total) and has no random-choice tiles", len(guaranteed), boardSize)
+		}
+
+		missing := boardSize - len(guaranteed)
+		for i := 0; i < missing; i++ {
+			pick := choices[rng.Intn(len(choices))]
+			guaranteed = append(guaranteed, pick)
+		}
+	}
+
+	// 3) If guaranteed exceeds board size, remove random extras.
+	if len(guaranteed) > boardSize {
+		rng.Shuffle(len(guaranteed), func(i, j int) { guaranteed[i], guaranteed[j] = guaranteed[j], guaranteed[i] })
+		guaranteed = guaranteed[:boardSize]
+		*deck = guaranteed
+	}
+
+	// Step 4: Shuffle final deck for unbiased placement later.
+	rng.Shuffle(len(guaranteed), func(i, j int) { guaranteed[i], guaranteed[j] = guaranteed[j], guaranteed[i] })
+
+	*deck = guaranteed
+
+	// Assign the board tiles to this
+	for i := 0; i < int(g.Width); i++ {
+		for j := 0; j < int(g.Height); j++ {
+			kind, success := enums.TileKindFromString(string(guaranteed[i*int(g.Height)+j].Tile.Name))
+			if !success {
+				return fmt.Errorf("invalid tile kind %s in deck", guaranteed[i*int(g.Height)+j].Tile.Name)
+			}
+
+			color := enums.TileColor(guaranteed[i*int(g.Height)+j].Tile.Color)
+			if color == enums.UnspecifiedColor {
+				color = enums.RandomColor(rng, true)
+			}
+
+			tile := &Tile{
+				Pos:   valueobjects.Point{X: uint16(i), Y: uint16(j)},
+				Color: color,
+				Open:  true,
+				Kind:  kind,
+				Data:  guaranteed[i*int(g.Height)+j].Tile.Data,
+			}
+			g.Tiles[j][i] = *tile
+		}
+	}
+	return nil
+}
@@ -0,0 +1,397 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"omgtant/claustroboard/shared/dtos"
+	"omgtant/claustroboard/shared/enums"
+	"omgtant/claustroboard/shared/valueobjects"
+	"slices"
+)
+
+// Game holds the rules of a single match, without any registry, storage or I/O,
+// so that it can be played from tests, bots or tools as well as by the server.
+// It is not safe for concurrent use.
+type Game struct {
+	Width      uint16               `json:"width"`
+	Height     uint16               `json:"height"`
+	Tiles      [][]Tile             `json:"tiles"`
+	Players    []string             `json:"players"`
+	Pos        []valueobjects.Point `json:"pos"`
+	IsActive   []bool               `json:"active"`
+	Turn       uint32               `json:"turn"`
+	Round      uint32               `json:"round"`      // Number of times the turn order wrapped around, passed to tile turn start hooks
+	CheckTurn  uint32               `json:"checkTurn"`  // Used in netcode to ensure clients are in sync, along with StateHash
+	Eliminated []int                `json:"eliminated"` // Player indices in the order they lost
+	Over       bool                 `json:"over"`
+	Seed       int64                `json:"seed"`
+}
+
+// New deals a board from the deck of cfg and places the players on it.
+// The same config, players and seed always yield the same game.
+func New(cfg dtos.GameConfig, players []string, seed int64) (*Game, error) {
+	g := &Game{
+		Width:   uint16(cfg.Width),
+		Height:  uint16(cfg.Height),
+		Players: slices.Clone(players),
+		Seed:    seed,
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	if err := g.deal(rng, cfg.Deck); err != nil {
+		return nil, err
+	}
+	if err := g.place(rng); err != nil {
+		return nil, err
+	}
+
+	g.runTurnStartHooks()
+	g.checkNextForDeadness()
+	return g, nil
+}
+
+// place puts every player on a random tile they may start on.
+func (g *Game) place(rng *rand.Rand) error {
+	total := int(g.Width) * int(g.Height)
+	if len(g.Players) > total {
+		return errors.New("not enough tiles for players")
+	}
+
+	// Find valid starting positions
+	validPositions := []valueobjects.Point{}
+	for y := uint16(0); y < g.Height; y++ {
+		for x := uint16(0); x < g.Width; x++ {
+			pos := valueobjects.Point{X: x, Y: y}
+			if tile, err := g.getTileAt(pos); err == nil && tile.CanStart() {
+				validPositions = append(validPositions, pos)
+			}
+		}
+	}
+
+	if len(validPositions) < len(g.Players) {
+		return errors.New("not enough valid starting positions")
+	}
+
+	// Randomly assign positions to players
+	g.Pos = make([]valueobjects.Point, 0, len(g.Players))
+	for range g.Players {
+		idx := rng.Intn(len(validPositions))
+		g.Pos = append(g.Pos, validPositions[idx])
+		validPositions = append(validPositions[:idx], validPositions[idx+1:]...)
+	}
+
+	// Mark all players as active
+	g.IsActive = make([]bool, len(g.Players))
+	for i := range g.IsActive {
+		g.IsActive[i] = true
+	}
+	return nil
+}
+
+// State returns a copy of the game as sent to clients.
+func (g *Game) State() *dtos.Board {
+	cpPlayers := make([]dtos.Player, len(g.Players))
+	for i, playerName := range g.Players {
+		cpPlayers[i] = dtos.Player{
+			Name:   playerName,
+			Pos:    g.Pos[i],
+			Active: g.IsActive[i],
+		}
+	}
+
+	palette := make(dtos.Palette)
+	for _, kind := range enums.TileKindNames {
+		palette[kind] = dtos.PaletteTile{}
+	}
+	dtsTiles := make([][]dtos.BoardTile, g.Height)
+	for y := uint16(0); y < g.Height; y++ {
+		dtsTiles[y] = make([]dtos.BoardTile, g.Width)
+		for x := uint16(0); x < g.Width; x++ {
+			tile := g.Tiles[y][x]
+			dtsTiles[y][x] = dtos.BoardTile{
+				Name:   enums.TileKindName(tile.Kind.String()),
+				Color:  tile.Color,
+				Data:   tile.Data,
+				Closed: !tile.Open,
+			}
+		}
+	}
+
+	return &dtos.Board{
+		Palette: palette,
+		Width:   g.Width,
+		Height:  g.Height,
+		Players: cpPlayers,
+		Tiles:   dtsTiles,
+		Turn:    g.CheckTurn,
+		Current: g.CurPlayer(),
+		Round:   g.Round,
+		Seed:    g.Seed,
+		Hash:    g.StateHash(),
+	}
+}
+
+func (g *Game) getTileAt(p valueobjects.Point) (t *Tile, internalError error) {
+	if p.Y >= g.Height || p.X >= g.Width {
+		return nil, errors.New("point out of bounds")
+	}
+	t = &g.Tiles[p.Y][p.X]
+	return
+}
+
+func (g *Game) GetCurrent() (t *Tile, index int, internalError error) {
+	if len(g.Pos) <= 0 {
+		return nil, 0, errors.New("game has no players")
+	}
+	index = g.CurPlayer()
+	t, internalError = g.getTileAt(g.Pos[index])
+	return t, index, internalError
+}
+
+func (g *Game) CurPlayer() int {
+	if len(g.Pos) == 0 {
+		return 0
+	}
+	return int(g.Turn) % len(g.Pos)
+}
+
+// LegalMoves lists the tiles the current player may walk to.
+func (g *Game) LegalMoves() []valueobjects.Point {
+	if g.Over {
+		return nil
+	}
+	from, p, err := g.GetCurrent()
+	if err != nil {
+		return nil
+	}
+	return from.AvailableMoves(g, p)
+}
+
+// Apply plays a whole delta for the current player: the walk destination followed
+// by the choices required by the tiles landed on. Either every step applies or none does:
+// the delta is played on a copy of the game, which replaces it once it succeeded.
+func (g *Game) Apply(moves []dtos.Move) (*dtos.Delta, error) {
+	next := g.Clone()
+	delta, err := next.apply(moves)
+	if err != nil {
+		return nil, err
+	}
+	*g = *next
+	return delta, nil
+}
+
+// apply plays a delta like Apply, but in place: g is left half changed when it fails.
+func (g *Game) apply(moves []dtos.Move) (*dtos.Delta, error) {
+	if g.Over {
+		return nil, errors.New("game is over")
+	}
+	if len(moves) == 0 {
+		return nil, errors.New("empty move")
+	}
+
+	from, _, err := g.GetCurrent()
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := moves[0].GetPoint()
+	if err != nil {
+		return nil, err
+	}
+
+	toTile, err := g.getTileAt(to)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := g.checkMoveValidity(from, toTile); err != nil {
+		return nil, err
+	}
+
+	choices := &Choices{steps: moves[1:]}
+	land, err := from.applyMove(g, toTile, choices)
+	if err == nil && !land {
+		err = errors.New("move does not end the turn")
+	}
+	if err == nil && choices.remaining() > 0 {
+		err = fmt.Errorf("%d unexpected choices after the move", choices.remaining())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	g.CheckTurn++
+	g.nextActivePlayer()
+	// Kill the next player now if it can't move
+	g.checkNextForDeadness()
+
+	return &dtos.Delta{Turn: g.CheckTurn, Move: &moves[0], Steps: moves, Hash: g.StateHash()}, nil
+}
+
+// Timeout ends the turn of the current player, who ran out of time, as policy says.
+// Random moves draw from rng.
+func (g *Game) Timeout(policy dtos.TimeoutPolicy, rng *rand.Rand) (*dtos.Delta, error) {
+	if g.Over {
+		return nil, errors.New("game is over")
+	}
+
+	switch policy {
+	case dtos.TimeoutRandom:
+		deltas := g.LegalDeltas()
+		if len(deltas) == 0 {
+			return nil, errors.New("no legal move to play")
+		}
+		delta, err := g.Apply(deltas[rng.Intn(len(deltas))])
+		if err != nil {
+			return nil, err
+		}
+		delta.Timeout = policy
+		return delta, nil
+	case dtos.TimeoutSkip:
+		g.CheckTurn++
+		g.nextActivePlayer()
+		g.checkNextForDeadness()
+	case dtos.TimeoutEliminate:
+		g.CheckTurn++
+		g.forfeit(g.CurPlayer())
+	default:
+		return nil, fmt.Errorf("unknown timeout policy %q", policy)
+	}
+	return &dtos.Delta{Turn: g.CheckTurn, Timeout: policy, Hash: g.StateHash()}, nil
+}
+
+// Forfeit eliminates player p out of turn, e.g. when they leave the game, as a turn
+// of its own. It returns the delta recording it, or nil when p was already out.
+func (g *Game) Forfeit(p int) *dtos.Delta {
+	if g.Over || !g.IsActive[p] {
+		return nil
+	}
+	g.CheckTurn++
+	g.forfeit(p)
+	return &dtos.Delta{Turn: g.CheckTurn, Forfeit: &p, Hash: g.StateHash()}
+}
+
+func (g *Game) forfeit(p int) {
+	current := g.CurPlayer() == p
+	g.eliminate(p)
+	if current && !g.Over {
+		g.nextActivePlayer()
+		g.checkNextForDeadness()
+	}
+}
+
+// Winner returns the player left standing once the game is over.
+func (g *Game) Winner() (p int, ok bool) {
+	if !g.Over {
+		return -1, false
+	}
+	for i, active := range g.IsActive {
+		if active {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// Result returns the placements once the game is over: the remaining player
+// first, then everyone else in the reverse order of losing.
+func (g *Game) Result() *dtos.GameResult {
+	if !g.Over {
+		return nil
+	}
+
+	result := &dtos.GameResult{Placements: []dtos.Placement{}}
+	if winner, ok := g.Winner(); ok {
+		result.Placements = append(result.Placements, dtos.Placement{Place: 1, Name: g.Players[winner]})
+	}
+	for i := len(g.Eliminated) - 1; i >= 0; i-- {
+		result.Placements = append(result.Placements, dtos.Placement{
+			Place: len(result.Placements) + 1,
+			Name:  g.Players[g.Eliminated[i]],
+		})
+	}
+	return result
+}
+
+// advanceTurn passes the turn to the next seat, starting a new round
+// when the turn order wraps around.
+func (g *Game) advanceTurn() {
+	g.Turn++
+	if g.Turn%uint32(len(g.Pos)) == 0 {
+		g.Round++
+		g.runTurnStartHooks()
+	}
+}
+
+// nextActivePlayer advances the turn, skipping dead players' moves.
+func (g *Game) nextActivePlayer() {
+	g.advanceTurn()
+	for !g.IsActive[g.CurPlayer()] {
+		g.advanceTurn()
+	}
+}
+
+// runTurnStartHooks triggers the turn start hook of every tile for the current round.
+func (g *Game) runTurnStartHooks() {
+	for y := range g.Tiles {
+		for x := range g.Tiles[y] {
+			g.Tiles[y][x].onTurnStart(g, g.Round)
+		}
+	}
+}
+
+func (g *Game) checkMoveValidity(from *Tile, to *Tile) error {
+	validMoves := from.AvailableMoves(g, g.CurPlayer())
+	destPoint := to.Pos
+	destTile, err := g.getTileAt(destPoint)
+	if !slices.Contains(validMoves, destPoint) || err != nil || destTile == nil {
+		return fmt.Errorf("invalid move from %v to %v: %v", from.Pos, destPoint, err)
+	}
+
+	return nil
+}
+
+func (g *Game) getPlayerAt(p valueobjects.Point) int {
+	for i, pos := range g.Pos {
+		if pos == p {
+			return i
+		}
+	}
+	return -1
+}
+
+// checkNextForDeadness eliminates the current player while they cannot move,
+// passing the turn on until someone can move or the game is over.
+func (g *Game) checkNextForDeadness() {
+	for !g.Over {
+		nextPlayerPos := g.Pos[g.CurPlayer()]
+		nextPlayerTile, err := g.getTileAt(nextPlayerPos)
+		if err != nil {
+			panic(fmt.Sprintf("Failed to get tile at %s: %v", nextPlayerPos.String(), err))
+		}
+		if len(nextPlayerTile.AvailableMoves(g, g.CurPlayer())) > 0 {
+			return
+		}
+		g.eliminate(g.CurPlayer())
+		if !g.Over {
+			g.nextActivePlayer()
+		}
+	}
+}
+
+// eliminate marks player p as out of the game, ending it when at most one player is left.
+func (g *Game) eliminate(p int) {
+	g.IsActive[p] = false
+	g.Eliminated = append(g.Eliminated, p)
+
+	activeCount := 0
+	for _, active := range g.IsActive {
+		if active {
+			activeCount++
+		}
+	}
+	if activeCount <= 1 {
+		g.Over = true
+	}
+}
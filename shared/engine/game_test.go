@@ -0,0 +1,261 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"omgtant/claustroboard/shared/dtos"
+	"omgtant/claustroboard/shared/enums"
+	"reflect"
+	"testing"
+)
+
+func testConfig(t *testing.T, deck string) dtos.GameConfig {
+	t.Helper()
+	var cfg dtos.GameConfig
+	if err := json.Unmarshal([]byte(`{"width":4,"height":4,"deck":`+deck+`}`), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+const mixedDeck = `[
+	{"tile": {"tile_type": "Layout", "data": {"energy": 2}}, "count": null},
+	{"tile": {"tile_type": "Wildcard"}, "count": 2},
+	{"tile": {"tile_type": "Teleport"}, "count": 1},
+	{"tile": {"tile_type": "Zero"}, "count": 1},
+	{"tile": {"tile_type": "Wall"}, "count": 1}
+]`
+
+func TestNewIsDeterministic(t *testing.T) {
+	cfg := testConfig(t, mixedDeck)
+	players := []string{"a", "b", "c"}
+
+	g1, err := New(cfg, players, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g2, err := New(cfg, players, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(g1.State(), g2.State()) {
+		t.Errorf("same seed dealt different games:\n%v\n%v", renderBoard(g1), renderBoard(g2))
+	}
+	if g1.State().Seed != 42 {
+		t.Errorf("seed %d not recorded in the state", g1.State().Seed)
+	}
+
+	for seed := int64(0); seed < 100; seed++ {
+		g3, err := New(cfg, players, seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(g1.Tiles, g3.Tiles) || !reflect.DeepEqual(g1.Pos, g3.Pos) {
+			return
+		}
+	}
+	t.Error("every seed dealt the same game")
+}
+
+func TestNewPlacesPlayers(t *testing.T) {
+	cfg := testConfig(t, mixedDeck)
+	g, err := New(cfg, []string{"a", "b"}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if g.Pos[0] == g.Pos[1] {
+		t.Errorf("players share tile %v", g.Pos[0])
+	}
+	for i, p := range g.Pos {
+		tile, err := g.getTileAt(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !tile.CanStart() {
+			t.Errorf("player %d starts on a %s", i, tile.Kind)
+		}
+	}
+	for _, row := range g.Tiles {
+		for _, tile := range row {
+			if tile.Kind == enums.Wall && tile.Open {
+				t.Errorf("wall at %v still open after setup", tile.Pos)
+			}
+		}
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		deck    string
+		players []string
+	}{
+		{"no starting tile", `[{"tile": {"tile_type": "Wall"}, "count": null}]`, []string{"a"}},
+		{"underfilled deck", `[{"tile": {"tile_type": "Layout", "data": {"energy": 1}}, "count": 3}]`, []string{"a"}},
+		{"unknown kind", `[{"tile": {"tile_type": "Lava"}, "count": null}]`, []string{"a"}},
+		{"more players than tiles", mixedDeck, make([]string, 17)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(testConfig(t, tt.deck), tt.players, 1); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestResult(t *testing.T) {
+	g := &Game{
+		Players:    []string{"a", "b", "c"},
+		IsActive:   []bool{false, true, false},
+		Eliminated: []int{2, 0},
+	}
+	if g.Result() != nil {
+		t.Error("result of a game that is not over")
+	}
+
+	g.Over = true
+	want := []dtos.Placement{{Place: 1, Name: "b"}, {Place: 2, Name: "a"}, {Place: 3, Name: "c"}}
+	if got := g.Result().Placements; !reflect.DeepEqual(got, want) {
+		t.Errorf("placements %v, want %v", got, want)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	board := scenario{
+		Board: []string{
+			"L1 L1 L1",
+			"L1 L1 L1",
+		},
+		Players: []scenarioSeat{{At: "0,0"}, {At: "2,1"}, {At: "2,0"}},
+	}
+
+	tests := []struct {
+		policy     dtos.TimeoutPolicy
+		moved      bool
+		current    int
+		eliminated []int
+	}{
+		{dtos.TimeoutRandom, true, 1, nil},
+		{dtos.TimeoutSkip, false, 1, nil},
+		{dtos.TimeoutEliminate, false, 1, []int{0}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			g, err := board.game()
+			if err != nil {
+				t.Fatal(err)
+			}
+			delta, err := g.Timeout(tt.policy, rand.New(rand.NewSource(1)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if delta.Timeout != tt.policy || delta.Turn != 1 || g.CheckTurn != 1 {
+				t.Errorf("delta %+v after turn %d", delta, g.CheckTurn)
+			}
+			if moved := g.Pos[0] != point(0, 0); moved != tt.moved || (delta.Move != nil) != tt.moved {
+				t.Errorf("player at %v, move %v", g.Pos[0], delta.Move)
+			}
+			if g.CurPlayer() != tt.current {
+				t.Errorf("current player %d, want %d", g.CurPlayer(), tt.current)
+			}
+			if !reflect.DeepEqual(g.Eliminated, tt.eliminated) {
+				t.Errorf("eliminated %v, want %v", g.Eliminated, tt.eliminated)
+			}
+		})
+	}
+
+	g, _ := board.game()
+	if _, err := g.Timeout("pass", rand.New(rand.NewSource(1))); err == nil {
+		t.Error("expected an error for an unknown policy")
+	}
+}
+
+func TestStateHash(t *testing.T) {
+	board := scenario{
+		Board: []string{
+			"L1 L1 L1",
+			"L1 L2 L1",
+		},
+		Players: []scenarioSeat{{At: "0,0"}, {At: "2,1"}},
+	}
+	g, err := board.game()
+	if err != nil {
+		t.Fatal(err)
+	}
+	same, _ := board.game()
+	initial := g.StateHash()
+	if len(initial) != 8 || initial != same.StateHash() {
+		t.Fatalf("hashes %s and %s of the same game", initial, same.StateHash())
+	}
+
+	// Only the content of tile data counts, not how it is encoded
+	same.Tiles[1][1].Data = map[string]json.RawMessage{"energy": json.RawMessage(" 2 ")}
+	if same.StateHash() != initial {
+		t.Error("reformatted tile data changed the hash")
+	}
+	same.Tiles[1][1].Open = false
+	if same.StateHash() == initial {
+		t.Error("closing a tile kept the hash")
+	}
+
+	// The same board with another player to move, or in another round
+	other, _ := board.game()
+	other.Turn++
+	if other.StateHash() == initial {
+		t.Error("passing the turn kept the hash")
+	}
+	other, _ = board.game()
+	other.Round++
+	if other.StateHash() == initial {
+		t.Error("starting a new round kept the hash")
+	}
+
+	delta, err := g.Apply([]dtos.Move{dtos.PointMove(point(1, 0))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delta.Hash == initial || delta.Hash != g.StateHash() || g.State().Hash != delta.Hash {
+		t.Errorf("delta hash %s, state hash %s, initial hash %s", delta.Hash, g.StateHash(), initial)
+	}
+}
+
+// failingTile changes every part of the game a hook can reach, then rejects the landing.
+type failingTile struct{ layoutTile }
+
+func (failingTile) CanLand(t *Tile, g *Game, p int) bool { return true }
+
+func (failingTile) OnPlayerLanding(t *Tile, g *Game, p int, c *Choices) (bool, error) {
+	g.IsActive[1] = false
+	g.Eliminated = append(g.Eliminated, 1)
+	g.Turn++
+	g.Round++
+	g.Over = true
+	t.Color = 9
+	return false, errors.New("landing refused")
+}
+
+func TestApplyIsAtomic(t *testing.T) {
+	const failingKind enums.TileKind = 100
+	RegisterTileBehavior(failingKind, failingTile{})
+
+	g, err := scenario{
+		Board:   []string{"L1 L1 L1"},
+		Players: []scenarioSeat{{At: "0,0"}, {At: "2,0"}},
+	}.game()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Tiles[0][1].Kind = failingKind
+	before := g.Clone()
+
+	if _, err := g.Apply([]dtos.Move{dtos.PointMove(point(1, 0))}); err == nil {
+		t.Fatal("expected the landing to fail")
+	}
+	if !reflect.DeepEqual(g, before) {
+		t.Errorf("failed delta changed the game:\n%+v\nwas\n%+v", g, before)
+	}
+}
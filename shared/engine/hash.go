@@ -0,0 +1,65 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// StateHash fingerprints everything a delta can change, so that clients can check
+// they applied it the same way as the server. It is the FNV-1a 32-bit hash, as
+// 8 hexadecimal digits, of the canonical form of the game:
+//
+//	turn;current;round;tile;...;tile;player;...;player
+//
+// where turn is CheckTurn, current the index of the player to move, round the
+// number of times the turn order wrapped around, tiles come in row-major order as kind,color,open,data
+// and players in turn order as x,y,active. open and active are 1 or 0, data lists
+// key=value pairs sorted by key and joined by &, with values as compact JSON.
+func (g *Game) StateHash() string {
+	var sb strings.Builder
+	sb.WriteString(strconv.FormatUint(uint64(g.CheckTurn), 10))
+	fmt.Fprintf(&sb, ";%d;%d", g.CurPlayer(), g.Round)
+	for _, row := range g.Tiles {
+		for _, tile := range row {
+			fmt.Fprintf(&sb, ";%s,%d,%d,%s", tile.Kind.String(), tile.Color, flag(tile.Open), canonicalData(tile.Data))
+		}
+	}
+	for i, pos := range g.Pos {
+		fmt.Fprintf(&sb, ";%d,%d,%d", pos.X, pos.Y, flag(g.IsActive[i]))
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(sb.String()))
+	return fmt.Sprintf("%08x", h.Sum32())
+}
+
+func canonicalData(data map[string]json.RawMessage) string {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		var value bytes.Buffer
+		if err := json.Compact(&value, data[k]); err != nil {
+			value.Reset()
+			value.Write(data[k])
+		}
+		pairs[i] = k + "=" + value.String()
+	}
+	return strings.Join(pairs, "&")
+}
+
+func flag(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,367 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"omgtant/claustroboard/shared/dtos"
+	"omgtant/claustroboard/shared/enums"
+	"omgtant/claustroboard/shared/valueobjects"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// A scenario is a board in the middle of a game, followed by steps that
+// play on it and check the outcome. Scenarios live in testdata/scenarios,
+// one file per topic, see testdata/README.md for the fixture format.
+type scenario struct {
+	Name    string         `json:"name"`
+	Board   []string       `json:"board"`
+	Players []scenarioSeat `json:"players"`
+	Turn    uint32         `json:"turn"`
+	Start   bool           `json:"start"` // Run the setup New does after placing players
+	Steps   []scenarioStep `json:"steps"`
+}
+
+type scenarioSeat struct {
+	At  string `json:"at"`
+	Out bool   `json:"out"`
+}
+
+type scenarioStep struct {
+	// Actions, at most one per step
+	Move    []json.RawMessage `json:"move"`
+	Reject  bool              `json:"reject"` // The move must fail and leave the game untouched
+	Forfeit *int              `json:"forfeit"`
+
+	// Assertions, checked after the action
+	Legal      *[]string `json:"legal"` // In any order
+	Pos        []string  `json:"pos"`
+	Active     []bool    `json:"active"`
+	Current    *int      `json:"current"`
+	Eliminated *[]int    `json:"eliminated"`
+	Over       *bool     `json:"over"`
+	Winner     *int      `json:"winner"`
+	Board      []string  `json:"board"`
+}
+
+func TestScenarios(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "scenarios", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no scenario fixtures found")
+	}
+
+	for _, file := range files {
+		buf, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var scenarios []scenario
+		if err := json.Unmarshal(buf, &scenarios); err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+
+		topic := strings.TrimSuffix(filepath.Base(file), ".json")
+		for _, sc := range scenarios {
+			t.Run(topic+"/"+sc.Name, func(t *testing.T) {
+				sc.run(t)
+			})
+		}
+	}
+}
+
+func (sc scenario) run(t *testing.T) {
+	g, err := sc.game()
+	if err != nil {
+		t.Fatalf("invalid fixture: %v", err)
+	}
+
+	for i, step := range sc.Steps {
+		if err := step.apply(g); err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+		for _, err := range step.check(g) {
+			t.Errorf("step %d: %v", i, err)
+		}
+		if t.Failed() {
+			t.Logf("board after step %d:\n%s", i, strings.Join(renderBoard(g), "\n"))
+			return
+		}
+	}
+}
+
+// game builds the game the fixture describes.
+func (sc scenario) game() (*Game, error) {
+	if len(sc.Board) == 0 {
+		return nil, fmt.Errorf("empty board")
+	}
+
+	g := &Game{
+		Height: uint16(len(sc.Board)),
+		Turn:   sc.Turn,
+	}
+	for y, row := range sc.Board {
+		tokens := strings.Fields(row)
+		if y == 0 {
+			g.Width = uint16(len(tokens))
+		} else if len(tokens) != int(g.Width) {
+			return nil, fmt.Errorf("row %d has %d tiles, expected %d", y, len(tokens), g.Width)
+		}
+
+		tiles := make([]Tile, len(tokens))
+		for x, token := range tokens {
+			tile, err := parseTile(token)
+			if err != nil {
+				return nil, fmt.Errorf("tile (%d,%d): %w", x, y, err)
+			}
+			tile.Pos = valueobjects.Point{X: uint16(x), Y: uint16(y)}
+			tiles[x] = tile
+		}
+		g.Tiles = append(g.Tiles, tiles)
+	}
+
+	for i, seat := range sc.Players {
+		p, err := parsePoint(seat.At)
+		if err != nil {
+			return nil, fmt.Errorf("player %d: %w", i, err)
+		}
+		g.Players = append(g.Players, fmt.Sprintf("p%d", i))
+		g.Pos = append(g.Pos, p)
+		g.IsActive = append(g.IsActive, !seat.Out)
+	}
+
+	if sc.Start {
+		g.runTurnStartHooks()
+		g.checkNextForDeadness()
+	}
+	return g, nil
+}
+
+func (step scenarioStep) apply(g *Game) error {
+	switch {
+	case step.Move != nil:
+		moves, err := parseMoves(step.Move)
+		if err != nil {
+			return err
+		}
+
+		before := renderBoard(g)
+		pos := slices.Clone(g.Pos)
+		_, err = g.Apply(moves)
+		if step.Reject {
+			if err == nil {
+				return fmt.Errorf("move %v was accepted", moves)
+			}
+			if !slices.Equal(before, renderBoard(g)) || !slices.Equal(pos, g.Pos) {
+				return fmt.Errorf("rejected move %v changed the game", moves)
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("move %v: %w", moves, err)
+		}
+	case step.Forfeit != nil:
+		g.Forfeit(*step.Forfeit)
+	}
+	return nil
+}
+
+func (step scenarioStep) check(g *Game) (errs []error) {
+	if step.Legal != nil {
+		want, err := parsePoints(*step.Legal)
+		if err != nil {
+			return []error{err}
+		}
+		got := g.LegalMoves()
+		if !sameSet(got, want) {
+			errs = append(errs, fmt.Errorf("legal moves %v, want %v", got, want))
+		}
+	}
+	if step.Pos != nil {
+		want, err := parsePoints(step.Pos)
+		if err != nil {
+			return []error{err}
+		}
+		if !slices.Equal(g.Pos, want) {
+			errs = append(errs, fmt.Errorf("positions %v, want %v", g.Pos, want))
+		}
+	}
+	if step.Active != nil && !slices.Equal(g.IsActive, step.Active) {
+		errs = append(errs, fmt.Errorf("active %v, want %v", g.IsActive, step.Active))
+	}
+	if step.Current != nil && g.CurPlayer() != *step.Current {
+		errs = append(errs, fmt.Errorf("current player %d, want %d", g.CurPlayer(), *step.Current))
+	}
+	if step.Eliminated != nil && !slices.Equal(g.Eliminated, *step.Eliminated) {
+		errs = append(errs, fmt.Errorf("eliminated %v, want %v", g.Eliminated, *step.Eliminated))
+	}
+	if step.Over != nil && g.Over != *step.Over {
+		errs = append(errs, fmt.Errorf("over %t, want %t", g.Over, *step.Over))
+	}
+	if step.Winner != nil {
+		winner, ok := g.Winner()
+		if !ok {
+			winner = -1
+		}
+		if winner != *step.Winner {
+			errs = append(errs, fmt.Errorf("winner %d, want %d", winner, *step.Winner))
+		}
+	}
+	if step.Board != nil {
+		want := make([]string, len(step.Board))
+		for i, row := range step.Board {
+			want[i] = strings.Join(strings.Fields(row), " ")
+		}
+		if got := renderBoard(g); !slices.Equal(got, want) {
+			errs = append(errs, fmt.Errorf("board\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n")))
+		}
+	}
+	return errs
+}
+
+var (
+	kindSymbols = map[byte]enums.TileKind{
+		'L': enums.Layout,
+		'T': enums.Teleport,
+		'W': enums.Wall,
+		'*': enums.Wildcard,
+		'Z': enums.Zero,
+	}
+	colorSymbols = map[byte]enums.TileColor{
+		'r': enums.Red,
+		'y': enums.Yellow,
+		'g': enums.Green,
+		'b': enums.Blue,
+	}
+)
+
+// parseTile reads a tile token: an optional # when closed, the kind symbol,
+// the energy of layouts and an optional color letter, e.g. "L2r" or "#*".
+func parseTile(token string) (Tile, error) {
+	tile := Tile{Open: true, Color: enums.ColorLess}
+	rest := token
+	if strings.HasPrefix(rest, "#") {
+		tile.Open = false
+		rest = rest[1:]
+	}
+	if rest == "" {
+		return tile, fmt.Errorf("empty tile %q", token)
+	}
+
+	kind, ok := kindSymbols[rest[0]]
+	if !ok {
+		return tile, fmt.Errorf("unknown kind in %q", token)
+	}
+	tile.Kind = kind
+	rest = rest[1:]
+
+	if digits := strings.TrimRight(rest, "rygb"); digits != "" {
+		energy, err := strconv.Atoi(digits)
+		if err != nil {
+			return tile, fmt.Errorf("invalid energy in %q", token)
+		}
+		tile.setEnergy(energy)
+		rest = rest[len(digits):]
+	}
+	if rest != "" {
+		color, ok := colorSymbols[rest[0]]
+		if !ok || len(rest) > 1 {
+			return tile, fmt.Errorf("invalid color in %q", token)
+		}
+		tile.Color = color
+	}
+	return tile, nil
+}
+
+// renderBoard writes the tiles back as the tokens parseTile reads.
+func renderBoard(g *Game) []string {
+	rows := make([]string, len(g.Tiles))
+	for y, row := range g.Tiles {
+		tokens := make([]string, len(row))
+		for x, tile := range row {
+			var sb strings.Builder
+			if !tile.Open {
+				sb.WriteByte('#')
+			}
+			for symbol, kind := range kindSymbols {
+				if kind == tile.Kind {
+					sb.WriteByte(symbol)
+				}
+			}
+			if energy := tile.getEnergy(); energy != 0 {
+				sb.WriteString(strconv.Itoa(energy))
+			}
+			for symbol, color := range colorSymbols {
+				if color == tile.Color {
+					sb.WriteByte(symbol)
+				}
+			}
+			tokens[x] = sb.String()
+		}
+		rows[y] = strings.Join(tokens, " ")
+	}
+	return rows
+}
+
+// parsePoint reads "x,y".
+func parsePoint(s string) (valueobjects.Point, error) {
+	xs, ys, ok := strings.Cut(s, ",")
+	x, errX := strconv.ParseUint(strings.TrimSpace(xs), 10, 16)
+	y, errY := strconv.ParseUint(strings.TrimSpace(ys), 10, 16)
+	if !ok || errX != nil || errY != nil {
+		return valueobjects.Point{}, fmt.Errorf("invalid point %q", s)
+	}
+	return valueobjects.Point{X: uint16(x), Y: uint16(y)}, nil
+}
+
+func parsePoints(ss []string) ([]valueobjects.Point, error) {
+	points := make([]valueobjects.Point, len(ss))
+	for i, s := range ss {
+		p, err := parsePoint(s)
+		if err != nil {
+			return nil, err
+		}
+		points[i] = p
+	}
+	return points, nil
+}
+
+// parseMoves reads the steps of a delta: "x,y" strings for points, numbers for indices.
+func parseMoves(raw []json.RawMessage) ([]dtos.Move, error) {
+	moves := make([]dtos.Move, len(raw))
+	for i, r := range raw {
+		var s string
+		if err := json.Unmarshal(r, &s); err == nil {
+			p, err := parsePoint(s)
+			if err != nil {
+				return nil, err
+			}
+			moves[i] = dtos.PointMove(p)
+			continue
+		}
+		var n int64
+		if err := json.Unmarshal(r, &n); err != nil {
+			return nil, fmt.Errorf("invalid move step %s", r)
+		}
+		moves[i] = dtos.IntMove(n)
+	}
+	return moves, nil
+}
+
+func sameSet(got, want []valueobjects.Point) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for _, p := range want {
+		if !slices.Contains(got, p) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,122 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"omgtant/claustroboard/shared/enums"
+	"omgtant/claustroboard/shared/valueobjects"
+
+	"math/rand"
+)
+
+type Tile struct {
+	Pos   valueobjects.Point
+	Open  bool
+	Color enums.TileColor
+	Kind  enums.TileKind
+	Data  map[string]json.RawMessage
+}
+
+func (t *Tile) getEnergy() int {
+	if energyData, exists := t.Data["energy"]; exists {
+		var energy int
+		json.Unmarshal(energyData, &energy)
+		return energy
+	}
+	return 0
+}
+
+func (t *Tile) setEnergy(e int) {
+	if t.Data == nil {
+		t.Data = make(map[string]json.RawMessage)
+	}
+	energyBytes, _ := json.Marshal(e)
+	t.Data["energy"] = energyBytes
+}
+
+func RandomizeTileKind(rng *rand.Rand, tk enums.TileKind, x, y uint16) *Tile {
+	tile := &Tile{
+		Pos:   valueobjects.Point{X: x, Y: y},
+		Open:  true,
+		Kind:  tk,
+		Color: enums.ColorLess,
+	}
+
+	switch tk {
+	case enums.Layout:
+		tile.setEnergy(1 + rng.Intn(4))
+		tile.Color = enums.RandomColor(rng, false)
+	case enums.Teleport:
+		tile.Color = enums.RandomColor(rng, true)
+	case enums.Wall:
+		tile.Open = false
+	case enums.Zero:
+		tile.Color = enums.RandomColor(rng, false)
+	}
+
+	return tile
+}
+
+func RandomizeTile(rng *rand.Rand, x, y uint16) *Tile {
+	return RandomizeTileKind(rng, enums.RandomKind(rng), x, y)
+}
+
+func (t1 Tile) CopyFor(p valueobjects.Point) (t2 Tile) {
+	t2.Pos = p
+	t2.Open = t1.Open
+	t2.Color = t1.Color
+	t2.Kind = t1.Kind
+	t2.Data = map[string]json.RawMessage{}
+	for k, v := range t1.Data {
+		t2.Data[k] = v
+	}
+
+	return
+}
+
+func (t Tile) CanLand(g *Game, p int) bool {
+	if !t.Open || g.getPlayerAt(t.Pos) != -1 {
+		return false
+	}
+	tb, ok := behaviorFor(t.Kind)
+	if !ok {
+		return false
+	}
+	return tb.CanLand(&t, g, p)
+}
+
+func (t Tile) CanStart() bool {
+	tb, ok := behaviorFor(t.Kind)
+	return ok && tb.CanStart(&t)
+}
+
+func (from Tile) AvailableMoves(g *Game, p int) (result []valueobjects.Point) {
+	tb, ok := behaviorFor(from.Kind)
+	if !ok {
+		return nil
+	}
+	return tb.AvailableMoves(&from, g, p)
+}
+
+func (t *Tile) onTurnStart(g *Game, turn uint32) {
+	if tb, ok := behaviorFor(t.Kind); ok {
+		tb.OnTurnStart(t, g, turn)
+	}
+}
+
+func (t *Tile) onPlayerLanding(g *Game, p int, c *Choices) (bool, error) {
+	tb, ok := behaviorFor(t.Kind)
+	if !ok {
+		return false, fmt.Errorf("unknown tile kind %s at %s", t.Kind.String(), t.Pos.String())
+	}
+	return tb.OnPlayerLanding(t, g, p, c)
+}
+
+func (from *Tile) applyMove(g *Game, dest *Tile, c *Choices) (land bool, err error) {
+	from.Open = false
+
+	player := g.CurPlayer()
+	g.Pos[player] = dest.Pos
+
+	return dest.onPlayerLanding(g, player, c)
+}
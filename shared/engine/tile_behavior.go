@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"omgtant/claustroboard/shared/dtos"
+	"omgtant/claustroboard/shared/enums"
+	"omgtant/claustroboard/shared/valueobjects"
+	"sync"
+)
+
+// TileBehavior holds the rules of a single tile kind.
+// See docs/Game Logic.md for the meaning of each hook.
+type TileBehavior interface {
+	// OnTurnStart is triggered for every tile once per round.
+	OnTurnStart(t *Tile, g *Game, turn uint32)
+	// AvailableMoves lists the tiles player p may move to when standing on t.
+	AvailableMoves(t *Tile, g *Game, p int) []valueobjects.Point
+	// OnPlayerLanding is triggered when player p lands on t.
+	// Tiles that let the player choose something read it from c.
+	// It reports whether the turn ends there.
+	OnPlayerLanding(t *Tile, g *Game, p int, c *Choices) (land bool, err error)
+	// CanLand reports whether player p may land on t.
+	// Open and occupied checks are done by the caller.
+	CanLand(t *Tile, g *Game, p int) bool
+	// CanStart reports whether a player may be placed on t when the game starts.
+	CanStart(t *Tile) bool
+}
+
+var (
+	tileBehaviorsMu sync.RWMutex
+	tileBehaviors   = make(map[enums.TileKind]TileBehavior)
+)
+
+// RegisterTileBehavior makes a tile kind playable.
+// It is meant to be called from init and panics on duplicate kinds.
+func RegisterTileBehavior(kind enums.TileKind, tb TileBehavior) {
+	tileBehaviorsMu.Lock()
+	defer tileBehaviorsMu.Unlock()
+
+	if tb == nil {
+		panic("engine: RegisterTileBehavior behavior is nil")
+	}
+	if _, dup := tileBehaviors[kind]; dup {
+		panic("engine: RegisterTileBehavior called twice for kind " + kind.String())
+	}
+	tileBehaviors[kind] = tb
+}
+
+func behaviorFor(kind enums.TileKind) (TileBehavior, bool) {
+	tileBehaviorsMu.RLock()
+	defer tileBehaviorsMu.RUnlock()
+
+	tb, ok := tileBehaviors[kind]
+	return tb, ok
+}
+
+// Choices are the steps of a delta left after the walk destination,
+// consumed in order by landing hooks.
+type Choices struct {
+	steps []dtos.Move
+}
+
+var (
+	errChoiceMissing    = errors.New("move requires another choice")
+	errChoiceOutOfRange = errors.New("choice out of range")
+)
+
+// Next pops the next choice, failing if the delta has none left.
+func (c *Choices) Next() (dtos.Move, error) {
+	if c == nil || len(c.steps) == 0 {
+		return dtos.Move{}, errChoiceMissing
+	}
+	m := c.steps[0]
+	c.steps = c.steps[1:]
+	return m, nil
+}
+
+func (c *Choices) remaining() int {
+	if c == nil {
+		return 0
+	}
+	return len(c.steps)
+}
+
+// pickPoint resolves a choice among candidates, either by its point
+// or by its index in candidates.
+func pickPoint(m dtos.Move, candidates []valueobjects.Point) (valueobjects.Point, error) {
+	if p, err := m.GetPoint(); err == nil {
+		for _, c := range candidates {
+			if c == p {
+				return p, nil
+			}
+		}
+		return p, fmt.Errorf("%s is not a valid choice", p.String())
+	}
+	i, err := m.GetInt()
+	if err != nil {
+		return valueobjects.Point{}, err
+	}
+	if i < 0 || i >= int64(len(candidates)) {
+		return valueobjects.Point{}, fmt.Errorf("%w: %d not in [0;%d)", errChoiceOutOfRange, i, len(candidates))
+	}
+	return candidates[i], nil
+}
@@ -0,0 +1,30 @@
+package engine
+
+import (
+	"omgtant/claustroboard/shared/enums"
+	"omgtant/claustroboard/shared/valueobjects"
+)
+
+type layoutTile struct{}
+
+func init() {
+	RegisterTileBehavior(enums.Layout, layoutTile{})
+}
+
+func (layoutTile) OnTurnStart(t *Tile, g *Game, turn uint32) {}
+
+func (layoutTile) AvailableMoves(t *Tile, g *Game, p int) []valueobjects.Point {
+	return g.dfs(*t, p, t.getEnergy(), true, make(map[valueobjects.Point]bool))
+}
+
+func (layoutTile) OnPlayerLanding(t *Tile, g *Game, p int, c *Choices) (bool, error) {
+	return true, nil
+}
+
+func (layoutTile) CanLand(t *Tile, g *Game, p int) bool {
+	return true
+}
+
+func (layoutTile) CanStart(t *Tile) bool {
+	return true
+}
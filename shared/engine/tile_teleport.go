@@ -0,0 +1,65 @@
+package engine
+
+import (
+	"omgtant/claustroboard/shared/enums"
+	"omgtant/claustroboard/shared/valueobjects"
+)
+
+type teleportTile struct{}
+
+func init() {
+	RegisterTileBehavior(enums.Teleport, teleportTile{})
+}
+
+func (teleportTile) OnTurnStart(t *Tile, g *Game, turn uint32) {}
+
+func (teleportTile) AvailableMoves(t *Tile, g *Game, p int) []valueobjects.Point {
+	return teleportDestinations(t, g, p)
+}
+
+// The player picks the destination as the next choice of the delta,
+// either as a point or as an index into the destinations in row-major order.
+func (teleportTile) OnPlayerLanding(t *Tile, g *Game, p int, c *Choices) (bool, error) {
+	choice, err := c.Next()
+	if err != nil {
+		return false, err
+	}
+	dest, err := pickPoint(choice, teleportDestinations(t, g, p))
+	if err != nil {
+		return false, err
+	}
+	destTile, err := g.getTileAt(dest)
+	if err != nil {
+		return false, err
+	}
+
+	g.Pos[p] = dest
+	return destTile.onPlayerLanding(g, p, c)
+}
+
+func (teleportTile) CanLand(t *Tile, g *Game, p int) bool {
+	tile, err := g.getTileAt(g.Pos[p])
+	if err != nil {
+		return false
+	}
+	playerOnTeleport := tile.Kind == enums.Teleport
+
+	return len(teleportDestinations(t, g, p)) > 0 && !playerOnTeleport
+}
+
+func (teleportTile) CanStart(t *Tile) bool {
+	return false
+}
+
+// teleportDestinations lists the non-teleport tiles of the teleport's color
+// (any color if it is colorless) that player p can land on, in row-major order.
+func teleportDestinations(t *Tile, g *Game, p int) (result []valueobjects.Point) {
+	for _, row := range g.Tiles {
+		for _, tile := range row {
+			if tile.Kind != enums.Teleport && (t.Color == enums.ColorLess || tile.Color == t.Color) && tile.CanLand(g, p) {
+				result = append(result, tile.Pos)
+			}
+		}
+	}
+	return result
+}
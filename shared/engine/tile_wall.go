@@ -0,0 +1,37 @@
+package engine
+
+import (
+	"errors"
+	"omgtant/claustroboard/shared/enums"
+	"omgtant/claustroboard/shared/valueobjects"
+)
+
+type wallTile struct{}
+
+func init() {
+	RegisterTileBehavior(enums.Wall, wallTile{})
+}
+
+// Walls are dealt open like every card and close before the first turn.
+func (wallTile) OnTurnStart(t *Tile, g *Game, turn uint32) {
+	if turn == 0 {
+		t.Open = false
+	}
+}
+
+// No moves available for walls
+func (wallTile) AvailableMoves(t *Tile, g *Game, p int) []valueobjects.Point {
+	return nil
+}
+
+func (wallTile) OnPlayerLanding(t *Tile, g *Game, p int, c *Choices) (bool, error) {
+	return false, errors.New("cannot land on a wall")
+}
+
+func (wallTile) CanLand(t *Tile, g *Game, p int) bool {
+	return false
+}
+
+func (wallTile) CanStart(t *Tile) bool {
+	return false
+}
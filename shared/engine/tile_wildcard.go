@@ -0,0 +1,33 @@
+package engine
+
+import (
+	"omgtant/claustroboard/shared/enums"
+	"omgtant/claustroboard/shared/valueobjects"
+	"slices"
+)
+
+type wildcardTile struct{}
+
+func init() {
+	RegisterTileBehavior(enums.Wildcard, wildcardTile{})
+}
+
+func (wildcardTile) OnTurnStart(t *Tile, g *Game, turn uint32) {}
+
+// Reachable with 1 to 4 steps: staying on the tile is not a move.
+func (wildcardTile) AvailableMoves(t *Tile, g *Game, p int) []valueobjects.Point {
+	moves := g.dfs(*t, p, 4, false, make(map[valueobjects.Point]bool))
+	return slices.DeleteFunc(moves, func(m valueobjects.Point) bool { return m == t.Pos })
+}
+
+func (wildcardTile) OnPlayerLanding(t *Tile, g *Game, p int, c *Choices) (bool, error) {
+	return true, nil
+}
+
+func (wildcardTile) CanLand(t *Tile, g *Game, p int) bool {
+	return true
+}
+
+func (wildcardTile) CanStart(t *Tile) bool {
+	return true
+}
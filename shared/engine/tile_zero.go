@@ -0,0 +1,58 @@
+package engine
+
+import (
+	"omgtant/claustroboard/shared/enums"
+	"omgtant/claustroboard/shared/valueobjects"
+)
+
+type zeroTile struct{}
+
+func init() {
+	RegisterTileBehavior(enums.Zero, zeroTile{})
+}
+
+func (zeroTile) OnTurnStart(t *Tile, g *Game, turn uint32) {}
+
+// No moves available for zero tiles
+func (zeroTile) AvailableMoves(t *Tile, g *Game, p int) []valueobjects.Point {
+	return nil
+}
+
+// Rotates every active player's position in turn order, and replaces this tile
+// with a copy of the tile the next player stood on.
+func (zeroTile) OnPlayerLanding(t *Tile, g *Game, player int, c *Choices) (bool, error) {
+	n := len(g.Pos)
+
+	activePlayers := make([]int, 0, n)
+	currentActivePlayer := -1
+	for i := range g.Players {
+		if g.IsActive[i] {
+			activePlayers = append(activePlayers, i)
+			if i == player {
+				currentActivePlayer = len(activePlayers) - 1
+			}
+		}
+	}
+
+	m := len(activePlayers)
+
+	nextActivePlayer := (currentActivePlayer + 1) % m
+	nextPlayerTile, _ := g.getTileAt(g.Pos[activePlayers[nextActivePlayer]])
+	g.Tiles[t.Pos.Y][t.Pos.X] = nextPlayerTile.CopyFor(t.Pos)
+
+	playerPos := g.Pos[activePlayers[0]]
+	for i, cur := range activePlayers[:m-1] {
+		g.Pos[cur] = g.Pos[activePlayers[i+1]]
+	}
+	g.Pos[activePlayers[len(activePlayers)-1]] = playerPos
+
+	return true, nil
+}
+
+func (zeroTile) CanLand(t *Tile, g *Game, p int) bool {
+	return true
+}
+
+func (zeroTile) CanStart(t *Tile) bool {
+	return false
+}
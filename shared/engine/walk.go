@@ -0,0 +1,52 @@
+package engine
+
+import (
+	"omgtant/claustroboard/shared/valueobjects"
+	"slices"
+)
+
+func (g *Game) dfs(me Tile, player int, energy int, exact bool, visited map[valueobjects.Point]bool) (result []valueobjects.Point) {
+	if energy == 0 {
+		return []valueobjects.Point{me.Pos}
+	}
+
+	visited[me.Pos] = true
+
+	result = []valueobjects.Point{}
+
+	for _, him := range []*valueobjects.Point{
+		me.Pos.Top(int(g.Width), int(g.Height)),
+		me.Pos.Bottom(int(g.Width), int(g.Height)),
+		me.Pos.Left(int(g.Width), int(g.Height)),
+		me.Pos.Right(int(g.Width), int(g.Height)),
+	} {
+		if him == nil || visited[*him] {
+			continue
+		}
+
+		tile, err := g.getTileAt(*him)
+		if err != nil || !tile.CanLand(g, player) {
+			continue
+		}
+
+		for _, p := range g.dfs(*tile, player, energy-1, exact, visited) {
+			if !slices.Contains(result, p) {
+				result = append(result, p)
+			}
+		}
+	}
+
+	visited[me.Pos] = false
+
+	if exact {
+		return result
+	}
+
+	if true {
+		if !slices.Contains(result, me.Pos) {
+			result = append(result, me.Pos)
+		}
+	}
+
+	return result
+}
@@ -29,12 +29,13 @@ func (ss TileColor) String() string {
 	return TileColorNames[ss]
 }
 
-func RandomColor(withColorless bool) TileColor {
-	colors := slices.Collect(maps.Keys(TileColorNames))
+func RandomColor(rng *rand.Rand, withColorless bool) TileColor {
+	// Sorted so that a seeded rng always picks the same color
+	colors := slices.Sorted(maps.Keys(TileColorNames))
 	colors = slices.DeleteFunc(colors, func(c TileColor) bool {
 		return c == UnspecifiedColor || (!withColorless && c == ColorLess)
 	})
-	return colors[rand.Intn(len(colors))]
+	return colors[rng.Intn(len(colors))]
 }
 
 func (tc TileColor) IsZero() bool {
@@ -47,7 +47,7 @@ func (ss TileKind) String() string {
 	return string(TileKindNames[ss])
 }
 
-func RandomKind() TileKind {
-	kinds := slices.Collect(maps.Keys(TileKindNames))
-	return kinds[rand.Intn(len(kinds))]
+func RandomKind(rng *rand.Rand) TileKind {
+	kinds := slices.Sorted(maps.Keys(TileKindNames))
+	return kinds[rng.Intn(len(kinds))]
 }
@@ -0,0 +1,176 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// Every registered board is owned by a goroutine, its actor, which runs the
+// commands sent to the board one at a time. Board fields are only read and
+// written by commands, so every state transition is serialized without locks.
+type actor struct {
+	commands chan command
+	stopped  chan struct{}
+	stopOnce sync.Once
+}
+
+type command struct {
+	run  func() error
+	done chan error
+}
+
+// start runs the actor of the board until stop is called.
+func (b *Board) start() {
+	b.actor = &actor{
+		commands: make(chan command),
+		stopped:  make(chan struct{}),
+	}
+	go b.loop()
+}
+
+func (b *Board) loop() {
+	for {
+		select {
+		case cmd := <-b.actor.commands:
+			cmd.done <- b.run(cmd.run)
+		case <-b.actor.stopped:
+			return
+		}
+	}
+}
+
+// run runs a command of the board. A command that panics marks the board as
+// errored instead of crashing the server, and the board refuses every command since.
+func (b *Board) run(f func() error) (err error) {
+	if b.Phase == PhaseErrored {
+		return fmt.Errorf("%w: %s", ErrGameErrored, b.Code)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			// The panic is only logged, it may reveal internals to clients
+			err = fmt.Errorf("%w: %s", ErrGameErrored, b.Code)
+			b.crash(r, debug.Stack(), err)
+		}
+	}()
+	return f()
+}
+
+// crash marks the board as errored after a command panicked with r,
+// and logs what the board looked like for the bug report.
+func (b *Board) crash(r any, stack []byte, err error) {
+	b.Phase = PhaseErrored
+	b.FinishedAt = time.Now()
+	fmt.Printf("Game %s crashed: %v\n%s\nBoard: %s\n", b.Code, r, stack, b.dump())
+
+	// A restart must not bring the board back
+	forget(b.Code)
+	notifyLobby(b.Code, nil)
+
+	crashHandlerMu.RLock()
+	h := crashHandler
+	crashHandlerMu.RUnlock()
+	if h != nil {
+		go h(b.Code, err)
+	}
+}
+
+// dump encodes the board as far as its state allows.
+func (b *Board) dump() (s string) {
+	defer func() {
+		if r := recover(); r != nil {
+			s = fmt.Sprintf("unavailable: %v", r)
+		}
+	}()
+	rec, err := b.record()
+	if err != nil {
+		return "unavailable: " + err.Error()
+	}
+	buf, err := json.Marshal(rec)
+	if err != nil {
+		return "unavailable: " + err.Error()
+	}
+	return string(buf)
+}
+
+var (
+	crashHandlerMu sync.RWMutex
+	crashHandler   func(code GameCode, err error)
+)
+
+// HandleCrashes sets the function told about every board that crashed,
+// e.g. to warn its clients. It runs on its own goroutine.
+func HandleCrashes(f func(code GameCode, err error)) {
+	crashHandlerMu.Lock()
+	defer crashHandlerMu.Unlock()
+	crashHandler = f
+}
+
+// stop ends the actor once the board left the registry. Commands sent
+// afterwards fail with ErrGameNotFound.
+func (b *Board) stop() {
+	b.actor.stopOnce.Do(func() { close(b.actor.stopped) })
+}
+
+// exec runs f on the actor of the board and waits for its error.
+// f must not call exec itself, which would wait on its own actor forever.
+func (b *Board) exec(f func() error) error {
+	return b.send(f, nil)
+}
+
+// How long housekeeping over every board waits for each of them.
+const boardAnswerTimeout = 2 * time.Second
+
+var errBoardBusy = errors.New("board did not answer in time")
+
+// execTimeout runs f on the actor of the board like exec, but gives up with
+// errBoardBusy after timeout. f may still run afterwards, so whatever it
+// writes must only be read when it returned without an error.
+func (b *Board) execTimeout(f func() error, timeout time.Duration) error {
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	return b.send(f, t.C)
+}
+
+// send runs f on the actor and waits for its error, or until timeout fires.
+func (b *Board) send(f func() error, timeout <-chan time.Time) error {
+	cmd := command{run: f, done: make(chan error, 1)}
+	select {
+	case b.actor.commands <- cmd:
+	case <-b.actor.stopped:
+		return fmt.Errorf("%w: %s", ErrGameNotFound, b.Code)
+	case <-timeout:
+		return fmt.Errorf("%w: %s", errBoardBusy, b.Code)
+	}
+	select {
+	case err := <-cmd.done:
+		return err
+	case <-timeout:
+		return fmt.Errorf("%w: %s", errBoardBusy, b.Code)
+	}
+}
+
+// withBoard runs f on the actor of the board with the given code.
+func withBoard(code GameCode, f func(b *Board) error) error {
+	b, err := getBoard(code)
+	if err != nil {
+		return err
+	}
+	return b.exec(func() error { return f(b) })
+}
+
+// allBoards returns the registered boards, to send commands to
+// without holding the registry lock.
+func allBoards() []*Board {
+	gameBoardsMu.RLock()
+	defer gameBoardsMu.RUnlock()
+
+	boards := make([]*Board, 0, len(gameBoards))
+	for _, b := range gameBoards {
+		boards = append(boards, b)
+	}
+	return boards
+}
@@ -0,0 +1,143 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"omgtant/claustroboard/shared/dtos"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConcurrentCommands(t *testing.T) {
+	code, err := NewGameBoard([]string{"host"}, dtos.GameConfig{Width: 8, Height: 8, MaxPlayers: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Players join, and every other one leaves again, while others read the board
+	var wg sync.WaitGroup
+	for i := range 30 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p := fmt.Sprintf("p%d", i)
+			if err := Join(code, p); err != nil {
+				if !errors.Is(err, ErrGameFull) {
+					t.Error(err)
+				}
+				return
+			}
+			_, _ = Snapshot(code)
+			_ = ListLobbies()
+			if i%2 == 0 {
+				if _, err := Leave(code, p); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	players, _, err := PlayerList(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(players) > 16 || players[0] != "host" {
+		t.Errorf("players %v", players)
+	}
+	seen := make(map[string]bool)
+	for _, p := range players[1:] {
+		var i int
+		fmt.Sscanf(p, "p%d", &i)
+		if i%2 == 0 || seen[p] {
+			t.Errorf("players %v", players)
+		}
+		seen[p] = true
+	}
+
+	// Only one of the concurrent starts goes through, unless everyone but
+	// one player was stuck from the start, which allows a rematch
+	started := make(chan error, 5)
+	for range 5 {
+		go func() {
+			_, _, err := StartGame(code, "host")
+			started <- err
+		}()
+	}
+	ok := 0
+	for range 5 {
+		if err := <-started; err == nil {
+			ok++
+		} else if !errors.Is(err, ErrAlreadyStarted) {
+			t.Error(err)
+		}
+	}
+	if ok == 0 {
+		t.Errorf("%d starts succeeded", ok)
+	}
+}
+
+func TestCrashedCommand(t *testing.T) {
+	crashed := make(chan GameCode, 1)
+	HandleCrashes(func(code GameCode, err error) { crashed <- code })
+	defer HandleCrashes(nil)
+
+	code, err := NewGameBoard([]string{"host"}, dtos.GameConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewGameBoard([]string{"host"}, dtos.GameConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = withBoard(code, func(b *Board) error {
+		var players []string
+		_ = players[len(b.Players)]
+		return nil
+	})
+	if !errors.Is(err, ErrGameErrored) {
+		t.Fatalf("got %v, want ErrGameErrored", err)
+	}
+	if got := <-crashed; got != code {
+		t.Errorf("crash handler got %s, want %s", got, code)
+	}
+
+	// The board refuses every command since, other boards carry on
+	if _, err := Snapshot(code); !errors.Is(err, ErrGameErrored) {
+		t.Errorf("got %v, want ErrGameErrored", err)
+	}
+	if err := Join(other, "guest"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBusyBoard(t *testing.T) {
+	code, err := NewGameBoard([]string{"host"}, dtos.GameConfig{Public: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := getBoard(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	busy, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	go b.exec(func() error {
+		close(busy)
+		<-release
+		return nil
+	})
+	<-busy
+
+	// Listings do not wait for the board, housekeeping gives up on it
+	if !slices.ContainsFunc(ListLobbies(), func(l dtos.Lobby) bool { return l.Code == code.String() }) {
+		t.Errorf("busy board %s is not listed", code)
+	}
+	if err := b.execTimeout(func() error { return nil }, 10*time.Millisecond); !errors.Is(err, errBoardBusy) {
+		t.Errorf("got %v, want errBoardBusy", err)
+	}
+}
@@ -5,124 +5,240 @@ import (
 	"fmt"
 	"math/rand"
 	"omgtant/claustroboard/shared/dtos"
-	"omgtant/claustroboard/shared/enums"
-	"omgtant/claustroboard/shared/valueobjects"
+	"omgtant/claustroboard/shared/engine"
 	"slices"
 	"sync"
+	"time"
 )
 
 type BoardPhase string
 
 const (
-	PhaseLobby   BoardPhase = "lobby"
-	PhaseStarted BoardPhase = "started"
+	PhaseLobby    BoardPhase = "lobby"
+	PhaseStarted  BoardPhase = "started"
+	PhaseFinished BoardPhase = "finished"
+	PhaseErrored  BoardPhase = "errored" // A command crashed, see Board.run
 )
 
 type Board struct {
-	mu         sync.Mutex
+	Code       GameCode
 	Width      uint16
 	Height     uint16
 	MaxPlayers uint8
-	Tiles      [][]Tile
+	Public     bool // Listed in the lobby browser
+	Deck       []dtos.TileConfig
 	Players    []string
-	Turn       uint32
-	CheckTurn  uint32 // Used in netcode to ensure clients are in sync
-	Pos        []valueobjects.Point
-	IsActive   []bool
+	Host       string       // Player allowed to start the game and manage the lobby
+	Seed       int64        // Seed of the current game, drawn again for every rematch
+	Game       *engine.Game // Current or last game, nil until the first one starts
+	Initial    *dtos.Board  // State when the game started, for replays
+	Log        []dtos.Delta // Every delta applied since the game started
+	Result     *dtos.GameResult
+	FinishedAt time.Time
 	Phase      BoardPhase
+
+	TurnTimeLimit time.Duration      // Unlimited when 0
+	TimeoutPolicy dtos.TimeoutPolicy // What happens to players who run out of time
+	TurnDeadline  time.Time          // When the current player runs out of time, zero without a limit
+
+	tokens map[string]string          // Reconnect token of each seated player
+	bots   map[string]engine.BotLevel // Difficulty of each seat played by a bot
+	left   map[string]bool            // Players who left a started game, their seat is freed when it ends
+
+	actor *actor // Runs every command on the board, see exec
 }
 
 var (
 	gameBoardsMu sync.RWMutex
 	gameBoards   = make(map[GameCode]*Board)
+
+	codeRngMu sync.Mutex
+	codeRng   = rand.New(rand.NewSource(time.Now().UnixNano()))
 )
 
-func (b *Board) Lock()   { b.mu.Lock() }
-func (b *Board) Unlock() { b.mu.Unlock() }
+// TurnOutcome is what a command playing a turn did.
+type TurnOutcome struct {
+	Delta  *dtos.Delta
+	Result *dtos.GameResult // Set when the turn ended the game
+}
+
+// Departure is what a player leaving did to the board.
+type Departure struct {
+	WasStarted bool             // They forfeited a game in progress
+	WasHost    bool             // The host role moved on to someone else
+	Delta      *dtos.Delta      // Their forfeit, when they were still in the game
+	Result     *dtos.GameResult // Set when their forfeit ended the game
+}
 
+// NewGameBoard creates a board in the lobby phase, see NormalizeConfig for
+// the checks gameConfig goes through first.
 func NewGameBoard(players []string, gameConfig dtos.GameConfig) (GameCode, error) {
-	width := uint16(gameConfig.Width)
-	height := uint16(gameConfig.Height)
+	gameConfig, err := NormalizeConfig(gameConfig)
+	if err != nil {
+		return "", err
+	}
+
+	seed := randomSeed()
+	if gameConfig.Seed != nil {
+		seed = *gameConfig.Seed
+	}
 
-	board := Board{
-		Width:      width,
-		Height:     height,
+	board := &Board{
+		Width:      uint16(gameConfig.Width),
+		Height:     uint16(gameConfig.Height),
 		MaxPlayers: uint8(gameConfig.MaxPlayers),
+		Deck:       gameConfig.Deck,
+		Public:     gameConfig.Public,
 		Phase:      PhaseLobby,
+		Seed:       seed,
+
+		TurnTimeLimit: time.Duration(gameConfig.TurnTimeLimit) * time.Second,
+		TimeoutPolicy: gameConfig.TimeoutPolicy,
+	}
+	// Nobody else sees the board before it is registered
+	for _, p := range players {
+		if err := board.join(p); err != nil {
+			return "", err
+		}
 	}
 
-	board.Tiles = make([][]Tile, height)
-	for i := range board.Tiles {
-		board.Tiles[i] = make([]Tile, width)
+	if err := register(board); err != nil {
+		return "", err
 	}
+	err = board.exec(func() error {
+		board.persist()
+		notifyLobby(board.Code, board.listing())
+		return nil
+	})
+	return board.Code, err
+}
 
-	board.fillUsingDeck(&gameConfig.Deck)
+// register picks an unused code for the board, adds it to the registry and starts its actor.
+func register(b *Board) error {
+	gameBoardsMu.Lock()
+	defer gameBoardsMu.Unlock()
 
-	id := RandomGameCode()
+	codeRngMu.Lock()
+	id := RandomGameCode(codeRng)
 	var attempts int
 	for attempts = 0; attempts < 10; attempts++ {
 		if _, exists := gameBoards[id]; !exists {
 			break
 		}
-		id = RandomGameCode()
+		id = RandomGameCode(codeRng)
 	}
+	codeRngMu.Unlock()
 	if attempts == 10 {
-		return "", errors.New("failed to generate unique board ID after 10 attempts")
+		return errors.New("failed to generate unique board ID after 10 attempts")
 	}
 
-	gameBoardsMu.Lock()
-	gameBoards[id] = &board
-	gameBoardsMu.Unlock()
+	b.Code = id
+	gameBoards[id] = b
+	b.start()
+	return nil
+}
 
-	for _, p := range players {
-		err := Join(id, p)
-		if err != nil {
-			return "", err
+func Join(code GameCode, p string) error {
+	return withBoard(code, func(b *Board) error {
+		if err := b.join(p); err != nil {
+			return err
 		}
-	}
-	return id, nil
+		b.persist()
+		notifyLobby(code, b.listing())
+		return nil
+	})
 }
 
-func Join(id GameCode, p string) error {
-	board, err := GetBoard(id)
-	if err != nil {
-		return err
+func (b *Board) join(p string) error {
+	if b.Phase != PhaseLobby {
+		return ErrAlreadyStarted
 	}
-	if board.Phase != PhaseLobby {
-		return errors.New("cannot join game that has already started")
+	if slices.Contains(b.Players, p) {
+		return ErrNicknameTaken
 	}
-	if board.MaxPlayers > 0 && len(board.Players) >= int(board.MaxPlayers) {
-		return errors.New("game is full") // TODO specific error types so that backend knows what http code to return
+	if b.MaxPlayers > 0 && len(b.Players) >= int(b.MaxPlayers) {
+		return ErrGameFull
 	}
 
-	board.Players = append(board.Players, p)
-	gameBoardsMu.Lock()
-	gameBoards[id] = board
-	gameBoardsMu.Unlock()
+	b.Players = append(b.Players, p)
+	if b.Host == "" {
+		b.Host = p
+	}
 	return nil
 }
 
-func Leave(id GameCode, p string) error {
-	board, err := GetBoard(id)
-	if err != nil {
-		return err
+// Leave removes a player from the board. Once the game has started the seat
+// is kept so that turn order holds, and the player forfeits instead.
+func Leave(code GameCode, p string) (d Departure, err error) {
+	err = withBoard(code, func(b *Board) error {
+		d = b.leave(p)
+		return nil
+	})
+	return d, err
+}
+
+func (b *Board) leave(p string) (d Departure) {
+	i := slices.Index(b.Players, p)
+	if i == -1 || b.left[p] {
+		return d
+	}
+	delete(b.tokens, p)
+	if b.Phase != PhaseStarted {
+		delete(b.bots, p)
 	}
 
-	for i, player := range board.Players {
-		if player == p {
-			board.Players = append(board.Players[:i], board.Players[i+1:]...)
-			break
+	if b.Phase == PhaseStarted {
+		d.WasStarted = true
+		if b.left == nil {
+			b.left = make(map[string]bool)
+		}
+		b.left[p] = true
+		if d.Delta = b.Game.Forfeit(i); d.Delta != nil {
+			b.endTurn(d.Delta)
+			d.Result = b.Result
 		}
+	} else {
+		b.removeSeat(i)
 	}
+	if b.Host == p {
+		d.WasHost = true
+		b.migrateHost()
+	}
+	b.persist()
+	notifyLobby(b.Code, b.listing())
+	return d
+}
 
-	gameBoardsMu.Lock()
-	gameBoards[id] = board
-	gameBoardsMu.Unlock()
+// config returns the configuration the board was created with.
+func (b *Board) config() dtos.GameConfig {
+	seed := b.Seed
+	return dtos.GameConfig{
+		Version:    ConfigVersion,
+		Width:      int(b.Width),
+		Height:     int(b.Height),
+		MaxPlayers: int(b.MaxPlayers),
+		Deck:       b.Deck,
+		Public:     b.Public,
+		Seed:       &seed,
+
+		TurnTimeLimit: int(b.TurnTimeLimit / time.Second),
+		TimeoutPolicy: b.TimeoutPolicy,
+	}
+}
 
-	return nil
+// randomSeed picks the seed of a game created without one, or of a rematch.
+// It stays below 2^53 so that JavaScript clients read it back exactly.
+func randomSeed() int64 {
+	codeRngMu.Lock()
+	defer codeRngMu.Unlock()
+	return codeRng.Int63n(1 << 53)
+}
+
+func (b *Board) removeSeat(i int) {
+	b.Players = slices.Delete(b.Players, i, i+1)
 }
 
-func GetBoard(code GameCode) (*Board, error) {
+func getBoard(code GameCode) (*Board, error) {
 	gameBoardsMu.RLock()
 	board, exists := gameBoards[code]
 	gameBoardsMu.RUnlock()
@@ -131,379 +247,164 @@ func GetBoard(code GameCode) (*Board, error) {
 		return board, nil
 	}
 
-	return nil, fmt.Errorf("board with ID %s not found", code)
+	return nil, fmt.Errorf("%w: %s", ErrGameNotFound, code)
 }
 
-func StartGame(code GameCode) (*Board, error) {
-	board, err := GetBoard(code)
-	if err != nil {
-		return nil, err
-	}
-	if board.Phase == PhaseStarted {
-		return board, errors.New("already started")
-	}
-	total := int(board.Width) * int(board.Height)
-	if len(board.Players) > total {
-		return nil, errors.New("not enough tiles for players")
-	}
-
-	used := make(map[valueobjects.Point]bool, len(board.Players))
-	board.Pos = board.Pos[:0]
-	// Find valid starting positions
-	validPositions := []valueobjects.Point{}
-	for y := uint16(0); y < board.Height; y++ {
-		for x := uint16(0); x < board.Width; x++ {
-			pos := valueobjects.Point{X: x, Y: y}
-			if tile, err := board.getTileAt(pos); err == nil && tile.CanStart() {
-				validPositions = append(validPositions, pos)
-			}
+// StartGame starts the game, or a rematch once it is over, on behalf of the host.
+// It returns the state of the new game, and its result when it is over from the start.
+func StartGame(code GameCode, by string) (state *dtos.Board, result *dtos.GameResult, err error) {
+	err = withBoard(code, func(b *Board) error {
+		if err := b.checkHost(by); err != nil {
+			return err
 		}
-	}
+		if err := b.startGame(); err != nil {
+			return err
+		}
+		state, result = b.snapshot(), b.Result
+		return nil
+	})
+	return state, result, err
+}
 
-	if len(validPositions) < len(board.Players) {
-		return nil, errors.New("not enough valid starting positions")
+func (b *Board) startGame() error {
+	if b.Phase == PhaseStarted {
+		return ErrAlreadyStarted
+	}
+	if b.Phase == PhaseFinished {
+		// Rematch on a fresh set of tiles, without those who left
+		for p := range b.left {
+			b.removeSeat(slices.Index(b.Players, p))
+			delete(b.bots, p)
+		}
+		b.left = nil
+		b.Seed = randomSeed()
 	}
 
-	// Randomly assign positions to players
-	for range board.Players {
-		idx := rand.Intn(len(validPositions))
-		board.Pos = append(board.Pos, validPositions[idx])
-		used[validPositions[idx]] = true
-		validPositions = append(validPositions[:idx], validPositions[idx+1:]...)
+	game, err := engine.New(b.config(), b.Players, b.Seed)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidConfig, err)
 	}
 
-	// Mark all players as active
-	board.IsActive = make([]bool, len(board.Players))
-	for i := range board.IsActive {
-		board.IsActive[i] = true
+	b.Game = game
+	b.Log = nil
+	b.Result = nil
+	b.Phase = PhaseStarted
+	b.Initial = game.State()
+	// Everyone but one player may be stuck from the start
+	if game.Over {
+		b.finish()
 	}
+	b.resetTurnDeadline()
+	b.persist()
+	notifyLobby(b.Code, b.listing())
+	return nil
+}
 
-	board.Phase = PhaseStarted
-	gameBoardsMu.Lock()
-	gameBoards[code] = board
-	gameBoardsMu.Unlock()
-	return board, nil
+func Snapshot(code GameCode) (state *dtos.Board, err error) {
+	err = withBoard(code, func(b *Board) error {
+		state = b.snapshot()
+		return nil
+	})
+	return state, err
 }
 
-func Snapshot(code GameCode) (*dtos.Board, error) {
-	b, err := GetBoard(code)
-	if err != nil {
-		return nil, err
-	}
-	cpPlayers := make([]dtos.Player, len(b.Players))
-	for i, playerName := range b.Players {
-		cpPlayers[i] = dtos.Player{
-			Name: playerName,
-			Pos:  b.Pos[i],
+func (b *Board) snapshot() *dtos.Board {
+	if b.Game != nil {
+		state := b.Game.State()
+		if !b.TurnDeadline.IsZero() {
+			deadline := b.TurnDeadline
+			state.TurnDeadline = &deadline
 		}
+		return state
 	}
 
-	palette := make(dtos.Palette)
-	for _, kind := range enums.TileKindNames {
-		palette[kind] = dtos.PaletteTile{}
-	}
-	dtsTiles := make([][]dtos.BoardTile, b.Height)
-	for y := uint16(0); y < b.Height; y++ {
-		dtsTiles[y] = make([]dtos.BoardTile, b.Width)
-		for x := uint16(0); x < b.Width; x++ {
-			tile := b.Tiles[y][x]
-			dtsTiles[y][x] = dtos.BoardTile{
-				Name:  enums.TileKindName(tile.Kind.String()),
-				Color: tile.Color,
-				Data:  tile.Data,
-			}
-		}
+	// Tiles are only dealt when the game starts
+	players := make([]dtos.Player, len(b.Players))
+	for i, playerName := range b.Players {
+		players[i] = dtos.Player{Name: playerName}
 	}
-
 	return &dtos.Board{
-		Palette: palette,
+		Palette: dtos.Palette{},
 		Width:   b.Width,
 		Height:  b.Height,
-		Players: cpPlayers,
-		Tiles:   dtsTiles,
-	}, nil
-}
-
-func (b *Board) getTileAt(p valueobjects.Point) (t *Tile, internalError error) {
-	if p.Y >= b.Height || p.X >= b.Width {
-		return nil, errors.New("point out of bounds")
-	}
-	t = &b.Tiles[p.Y][p.X]
-	return
-}
-
-func (b *Board) GetCurrent() (t *Tile, index int, internalError error) {
-	if len(b.Pos) <= 0 {
-		return nil, 0, errors.New("game has not started")
+		Players: players,
+		Tiles:   [][]dtos.BoardTile{},
+		Seed:    b.Seed,
 	}
-	index = int(b.Turn) % len(b.Pos)
-	player := b.Pos[index]
-	t, internalError = b.getTileAt(player)
-	return t, index, internalError
 }
 
-func (b *Board) CurPlayer() int {
-	return int(b.Turn) % len(b.Pos);
+// PlayerList returns the seated players in turn order, and those of them played by bots.
+func PlayerList(code GameCode) (players, bots []string, err error) {
+	err = withBoard(code, func(b *Board) error {
+		players = slices.Clone(b.Players)
+		bots = []string{}
+		for _, p := range players {
+			if b.IsBot(p) {
+				bots = append(bots, p)
+			}
+		}
+		return nil
+	})
+	return players, bots, err
 }
 
-func (b *Board) Move(move dtos.Move) (*dtos.Delta, error) {
+// currentPlayer returns the nickname of the player whose turn it is.
+func (b *Board) currentPlayer() (string, error) {
 	if b.Phase != PhaseStarted {
-		return nil, errors.New("game has not started")
+		return "", ErrNotStarted
 	}
-
-	from, _, err := b.GetCurrent()
+	_, idx, err := b.Game.GetCurrent()
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-
-	to, err := move.GetPoint()
-	if err != nil {
-		return nil, err
-	}
-
-	toTile, err := b.getTileAt(to)
-	if err != nil {
-		return nil, err
-	}
-
-	if err := b.checkMoveValidity(from, toTile); err != nil {
-		return nil, err
-	}
-	
-	b.CheckTurn++
-	if from.applyMove(b, toTile) {
-		b.Turn++
-		// Skip dead players' moves
-		for !b.IsActive[(b.Turn)%uint32(len(b.Pos))] {
-			b.Turn++
-		}
-		// Kill the next player now if it can't move
-		checkNextForDeadness(b)
-	}
-	return &dtos.Delta{Turn: b.CheckTurn, Move: move}, nil
+	return b.Game.Players[idx], nil
 }
 
-func (b *Board) checkMoveValidity(from *Tile, to *Tile) error {
-	validMoves := from.AvailableMoves(b, b.CurPlayer())
-	destPoint := to.Pos
-	destTile, err := b.getTileAt(destPoint)
-	if !slices.Contains(validMoves, destPoint) || err != nil || destTile == nil {
-		return fmt.Errorf("invalid move from %v to %v: %v", from.Pos, destPoint, err)
-	}
-
-	return nil
-}
-
-func (b *Board) getPlayerAt(p valueobjects.Point) int {
-	for i, pos := range b.Pos {
-		if pos == p {
-			return i
-		}
-	}
-	return -1
-}
-
-func checkNextForDeadness(b *Board) {
-	nextPlayerPos := b.Pos[b.CurPlayer()]
-	nextPlayerTile, err := b.getTileAt(nextPlayerPos)
-	if err != nil {
-		panic(fmt.Sprintf("Failed to get tile at %s: %v", nextPlayerPos.String(), err))
-	}
-	moves := nextPlayerTile.AvailableMoves(b, b.CurPlayer())
-	length := len(moves)
-	if length == 0 {
-		b.IsActive[(b.Turn)%uint32(len(b.Pos))] = false
-		fmt.Printf("Player %d is out of the game\n", (b.Turn)%uint32(len(b.Pos)))
-		activeCount := 0
-		for _, active := range b.IsActive {
-			if active {
-				activeCount++
-			}
+// Move applies a whole delta for player, who must be the current player,
+// see engine.Game.Apply.
+func Move(code GameCode, player string, moves []dtos.Move) (out TurnOutcome, err error) {
+	err = withBoard(code, func(b *Board) error {
+		current, err := b.currentPlayer()
+		if err != nil {
+			return err
 		}
-		if activeCount <= 1 {
-			b.Phase = PhaseLobby
-			fmt.Println("Game over, returning to lobby")
+		if current != player {
+			return ErrNotYourTurn
 		}
-	}
-}
-
-func (b *Board) validateDist(src Tile, dest valueobjects.Point, distTarget int, exact bool) (*Tile, bool) {
-	println("validate", src.Kind, src.Pos.X, src.Pos.Y, dest.X, dest.Y, distTarget, exact)
-	visited := []Tile{src}
-	queue := []Tile{src}
-	dist := 1
-
-	for dist <= distTarget {
-		queueCopy := make([]Tile, len(queue))
-		copy(queueCopy, queue)
-		queue = []Tile{}
-
-		for _, v := range queueCopy {
-			neighborsMatrix := []valueobjects.Point{}
-			if v.Pos.X+1 < b.Width {
-				neighborsMatrix = append(neighborsMatrix, valueobjects.Point{X: v.Pos.X + 1, Y: v.Pos.Y})
-			}
-			if v.Pos.X > 0 {
-				neighborsMatrix = append(neighborsMatrix, valueobjects.Point{X: v.Pos.X - 1, Y: v.Pos.Y})
-			}
-			if v.Pos.Y+1 < b.Height {
-				neighborsMatrix = append(neighborsMatrix, valueobjects.Point{X: v.Pos.X, Y: v.Pos.Y + 1})
-			}
-			if v.Pos.Y > 0 {
-				neighborsMatrix = append(neighborsMatrix, valueobjects.Point{X: v.Pos.X, Y: v.Pos.Y - 1})
-			}
-
-		browse:
-			for _, p := range neighborsMatrix {
-				println("neigh", p.X, p.Y)
-				for _, v := range visited {
-					if p == v.Pos {
-						continue browse
-					}
-				}
-				candidate, _ := b.getTileAt(p)
-				candidateValid := candidate != nil && candidate.Open
-				candidateIsTarget := dest == candidate.Pos && (!exact || dist == distTarget)
-
-				println("candidate", candidate.Pos.X, candidate.Pos.Y, candidateValid, candidateIsTarget)
-				if !candidateValid {
-					if candidateIsTarget {
-						return nil, false
-					}
-					continue
-				}
-
-				if candidateIsTarget {
-					return candidate, true
-				}
-
-				visited = append(visited, *candidate)
-				queue = append(queue, *candidate)
-			}
-		}
-		dist++
-	}
-
-	return nil, false
+		out, err = b.move(moves)
+		return err
+	})
+	return out, err
 }
 
-func (b *Board) dfs(me Tile, player int, energy int, exact bool, visited map[valueobjects.Point]bool) (result []valueobjects.Point) {
-	if energy == 0 {
-		return []valueobjects.Point{me.Pos}
-	}
-
-	visited[me.Pos] = true
-
-	result = []valueobjects.Point{}
-
-	for _, him := range []*valueobjects.Point{
-		me.Pos.Top(int(b.Width), int(b.Height)),
-		me.Pos.Bottom(int(b.Width), int(b.Height)),
-		me.Pos.Left(int(b.Width), int(b.Height)),
-		me.Pos.Right(int(b.Width), int(b.Height)),
-	} {
-		if him == nil || visited[*him] {
-			continue
-		}
-
-		tile, err := b.getTileAt(*him)
-		if err != nil || !tile.CanLand(b, player) {
-			continue
-		}
-
-		for _, p := range b.dfs(*tile, player, energy-1, exact, visited) {
-			if !slices.Contains(result, p) {
-				result = append(result, p)
-			}
-		}
-	}
-
-	visited[me.Pos] = false
-
-	if exact {
-		return result
+func (b *Board) move(moves []dtos.Move) (TurnOutcome, error) {
+	if b.Phase != PhaseStarted {
+		return TurnOutcome{}, ErrNotStarted
 	}
 
-	if true {
-		if !slices.Contains(result, me.Pos) {
-			result = append(result, me.Pos)
-		}
+	delta, err := b.Game.Apply(moves)
+	if err != nil {
+		return TurnOutcome{}, fmt.Errorf("%w: %v", ErrInvalidMove, err)
 	}
 
-	return result
+	b.endTurn(delta)
+	return TurnOutcome{Delta: delta, Result: b.Result}, nil
 }
 
-func (b *Board) fillUsingDeck(deck *[]dtos.TileConfig) error {
-	if deck == nil {
-		return fmt.Errorf("deck is nil")
-	}
-
-	boardSize := int(b.Width) * int(b.Height)
-
-	// 1) Gather guaranteed tiles (with defined count), repeat as needed.
-	guaranteed := make([]dtos.TileConfig, 0, boardSize)
-	for _, d := range *deck {
-		if d.Count <= 0 {
-			continue
-		}
-		for i := 0; i < int(d.Count); i++ {
-			guaranteed = append(guaranteed, d)
-		}
-	}
-
-	// 2) If not enough guaranteed, fill remaining with random tiles chosen from "choice" tiles (count == nil).
-	if len(guaranteed) < boardSize {
-		choices := make([]dtos.TileConfig, 0)
-		for _, d := range *deck {
-			if d.Count < 0 {
-				choices = append(choices, d)
-			}
-		}
-		if len(choices) == 0 {
-			return fmt.Errorf("deck underfills the board (%d guaranteed < %d total) and has no random-choice tiles", len(guaranteed), boardSize)
-		}
-
-		missing := boardSize - len(guaranteed)
-		for i := 0; i < missing; i++ {
-			pick := choices[rand.Intn(len(choices))]
-			guaranteed = append(guaranteed, pick)
-		}
-	}
-
-	// 3) If guaranteed exceeds board size, remove random extras.
-	if len(guaranteed) > boardSize {
-		rand.Shuffle(len(guaranteed), func(i, j int) { guaranteed[i], guaranteed[j] = guaranteed[j], guaranteed[i] })
-		guaranteed = guaranteed[:boardSize]
-		*deck = guaranteed
+// endTurn records a delta the game just applied.
+func (b *Board) endTurn(delta *dtos.Delta) {
+	b.Log = append(b.Log, *delta)
+	if b.Game.Over {
+		b.finish()
 	}
+	b.resetTurnDeadline()
+	b.persist()
+}
 
-	// Step 4: Shuffle final deck for unbiased placement later.
-	rand.Shuffle(len(guaranteed), func(i, j int) { guaranteed[i], guaranteed[j] = guaranteed[j], guaranteed[i] })
-
-	*deck = guaranteed
-
-	// Assign the board tiles to this
-	for i := 0; i < int(b.Width); i++ {
-		for j := 0; j < int(b.Height); j++ {
-			kind, success := enums.TileKindFromString(string(guaranteed[i*int(b.Height)+j].Tile.Name))
-			if !success {
-				return fmt.Errorf("invalid tile kind %s in deck", guaranteed[i*int(b.Height)+j].Tile.Name)
-			}
-
-			color := enums.TileColor(guaranteed[i*int(b.Height)+j].Tile.Color)
-			if color == enums.UnspecifiedColor {
-				color = enums.RandomColor(true)
-			}
-
-			tile := &Tile{
-				Pos:   valueobjects.Point{X: uint16(i), Y: uint16(j)},
-				Color: color,
-				Open:  true,
-				Kind:  kind,
-				Data:  guaranteed[i*int(b.Height)+j].Tile.Data,
-			}
-			b.Tiles[j][i] = *tile
-		}
-	}
-	return nil
+// finish records the result of the game that just ended and archives it.
+func (b *Board) finish() {
+	b.Result = b.Game.Result()
+	b.Phase = PhaseFinished
+	b.FinishedAt = time.Now()
+	b.archive()
 }
@@ -0,0 +1,143 @@
+package models
+
+import (
+	"omgtant/claustroboard/shared/dtos"
+	"testing"
+)
+
+func TestLeaveIsLoggedAsTurn(t *testing.T) {
+	UseRepository(NewMemoryRepository())
+	seed := int64(7)
+	code, err := NewGameBoard([]string{"a", "b", "c"}, dtos.GameConfig{Width: 6, Height: 6, Seed: &seed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, _, err := StartGame(code, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	current := state.Players[state.Current].Name
+	d, err := Leave(code, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Delta == nil || d.Delta.Turn != state.Turn+1 || *d.Delta.Forfeit != state.Current {
+		t.Fatalf("forfeit delta %+v after turn %d", d.Delta, state.Turn)
+	}
+
+	after, err := Snapshot(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Turn != d.Delta.Turn || after.Hash != d.Delta.Hash || after.Current == state.Current {
+		t.Errorf("state at turn %d, current %d, hash %s after %+v", after.Turn, after.Current, after.Hash, d.Delta)
+	}
+	resync, err := Resync(code, &state.Turn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resync.Deltas) != 1 || resync.Deltas[0].Hash != d.Delta.Hash {
+		t.Errorf("resync %+v", resync)
+	}
+
+	// Leaving again changes nothing
+	if again, _ := Leave(code, current); again.Delta != nil {
+		t.Errorf("second forfeit %+v", again.Delta)
+	}
+}
+
+func TestResyncSnapshotsUnloggedChanges(t *testing.T) {
+	code, err := NewGameBoard([]string{"a", "b"}, dtos.GameConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, _, err := StartGame(code, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resync, err := Resync(code, &state.Turn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resync.Snapshot != nil {
+		t.Errorf("snapshot while the log is complete")
+	}
+
+	// A change that bypassed the log
+	_ = withBoard(code, func(b *Board) error {
+		b.Game.Tiles[0][0].Open = !b.Game.Tiles[0][0].Open
+		return nil
+	})
+	resync, err = Resync(code, &state.Turn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resync.Snapshot == nil || len(resync.Deltas) > 0 {
+		t.Errorf("resync %+v, want a snapshot", resync)
+	}
+}
+
+func TestReplayIncludesForfeits(t *testing.T) {
+	UseRepository(NewMemoryRepository())
+	seed := int64(7)
+	code, err := NewGameBoard([]string{"a", "b"}, dtos.GameConfig{Width: 6, Height: 6, Seed: &seed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := StartGame(code, "a"); err != nil {
+		t.Fatal(err)
+	}
+	d, err := Kick(code, "a", "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Result == nil {
+		t.Fatal("kicking the last opponent did not end the game")
+	}
+
+	replay, err := GetReplay(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := replay.Deltas[len(replay.Deltas)-1]
+	if last.Forfeit == nil || *last.Forfeit != 1 || last.Hash != d.Delta.Hash {
+		t.Errorf("last archived delta %+v, want the forfeit %+v", last, d.Delta)
+	}
+}
+
+func TestHistoryLeadsToCurrentState(t *testing.T) {
+	seed := int64(7)
+	code, err := NewGameBoard([]string{"a", "b", "c"}, dtos.GameConfig{Width: 6, Height: 6, Seed: &seed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lobby, deltas, err := History(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lobby.Players) != 3 || len(deltas) != 0 {
+		t.Errorf("lobby history %+v with %d deltas", lobby, len(deltas))
+	}
+
+	initial, _, err := StartGame(code, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Leave(code, "c"); err != nil {
+		t.Fatal(err)
+	}
+	current, err := Snapshot(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, deltas, err := History(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Hash != initial.Hash || len(deltas) != 1 || deltas[0].Hash != current.Hash {
+		t.Errorf("history from %s through %+v, want from %s to %s", state.Hash, deltas, initial.Hash, current.Hash)
+	}
+}
@@ -0,0 +1,142 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"omgtant/claustroboard/shared/dtos"
+	"omgtant/claustroboard/shared/engine"
+	"runtime/debug"
+	"slices"
+)
+
+// AddBot seats a bot of the given level on behalf of the host, and returns its nickname.
+func AddBot(code GameCode, by string, level engine.BotLevel) (nickname string, err error) {
+	if !slices.Contains(engine.BotLevels, level) {
+		return "", fmt.Errorf("%w: unknown bot difficulty %q", ErrNotAllowed, level)
+	}
+
+	err = withBoard(code, func(b *Board) error {
+		if err := b.checkHost(by); err != nil {
+			return err
+		}
+		nickname = b.botNickname()
+		if err := b.join(nickname); err != nil {
+			return err
+		}
+		if b.bots == nil {
+			b.bots = make(map[string]engine.BotLevel)
+		}
+		b.bots[nickname] = level
+		b.persist()
+		notifyLobby(code, b.listing())
+		return nil
+	})
+	return nickname, err
+}
+
+// IsBot reports whether the seat is played by a bot.
+func (b *Board) IsBot(nickname string) bool {
+	_, ok := b.bots[nickname]
+	return ok
+}
+
+// PlayBot makes the current player move if it is a bot, with the same checks as
+// any other move. It returns a nil outcome when it is not a bot's turn.
+// The bot thinks on a copy of the game, so that the board keeps answering
+// meanwhile, and its move is dropped if the turn ended in the meantime.
+func PlayBot(code GameCode) (out *TurnOutcome, err error) {
+	var turn botTurn
+	err = withBoard(code, func(b *Board) error {
+		turn, err = b.botTurn()
+		return err
+	})
+	if err != nil || turn.game == nil {
+		return nil, err
+	}
+
+	moves, err := playBot(turn)
+	var crash *botCrash
+	if errors.As(err, &crash) {
+		// Crash the board as if the bot had played on its actor
+		return nil, withBoard(code, func(b *Board) error {
+			err := fmt.Errorf("%w: %s", ErrGameErrored, b.Code)
+			b.crash(crash.value, crash.stack, err)
+			return err
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("bot %s: %w", turn.nickname, err)
+	}
+
+	err = withBoard(code, func(b *Board) error {
+		if b.Phase != PhaseStarted || b.Game.CheckTurn != turn.game.CheckTurn {
+			return nil
+		}
+		outcome, err := b.move(moves)
+		if err != nil {
+			return err
+		}
+		out = &outcome
+		return nil
+	})
+	return out, err
+}
+
+// botCrash is a panic of a bot, which plays away from the actor and its recover.
+type botCrash struct {
+	value any
+	stack []byte
+}
+
+func (c *botCrash) Error() string {
+	return fmt.Sprintf("bot panicked: %v", c.value)
+}
+
+// playBot lets the bot of turn play, turning its panics into a *botCrash.
+func playBot(turn botTurn) (moves []dtos.Move, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &botCrash{value: r, stack: debug.Stack()}
+		}
+	}()
+	return turn.bot.Play(turn.game)
+}
+
+// botTurn is what a bot needs to pick its move away from the board.
+type botTurn struct {
+	nickname string
+	bot      engine.Bot
+	game     *engine.Game // Copy of the game, nil when it is not a bot's turn
+}
+
+func (b *Board) botTurn() (botTurn, error) {
+	if b.Phase != PhaseStarted {
+		return botTurn{}, nil
+	}
+	current, err := b.currentPlayer()
+	if err != nil || !b.IsBot(current) {
+		return botTurn{}, err
+	}
+
+	// Seeded by the game so that replaying it gives the same bot moves
+	rng := rand.New(rand.NewSource(b.Game.Seed + int64(b.Game.CheckTurn)))
+	bot, err := newBot(b.bots[current], rng)
+	if err != nil {
+		return botTurn{}, err
+	}
+	return botTurn{nickname: current, bot: bot, game: b.Game.Clone()}, nil
+}
+
+// newBot creates the bots playing the turns, replaced in tests.
+var newBot = engine.NewBot
+
+// botNickname returns the first "Bot <n>" nobody is using.
+func (b *Board) botNickname() string {
+	for n := 1; ; n++ {
+		nickname := fmt.Sprintf("Bot %d", n)
+		if !slices.Contains(b.Players, nickname) {
+			return nickname
+		}
+	}
+}
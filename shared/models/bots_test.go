@@ -0,0 +1,50 @@
+package models
+
+import (
+	"errors"
+	"math/rand"
+	"omgtant/claustroboard/shared/dtos"
+	"omgtant/claustroboard/shared/engine"
+	"testing"
+)
+
+type panickingBot struct{}
+
+func (panickingBot) Play(g *engine.Game) ([]dtos.Move, error) {
+	panic("bot bug")
+}
+
+func TestBotPanicCrashesItsBoard(t *testing.T) {
+	newBot = func(engine.BotLevel, *rand.Rand) (engine.Bot, error) { return panickingBot{}, nil }
+	defer func() { newBot = engine.NewBot }()
+	crashed := make(chan GameCode, 1)
+	HandleCrashes(func(code GameCode, err error) { crashed <- code })
+	defer HandleCrashes(nil)
+
+	seed := int64(7)
+	code, err := NewGameBoard([]string{"host"}, dtos.GameConfig{Width: 6, Height: 6, Seed: &seed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AddBot(code, "host", engine.BotRandom); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := StartGame(code, "host"); err != nil {
+		t.Fatal(err)
+	}
+	// Whoever moves first, it is a bot
+	_ = withBoard(code, func(b *Board) error {
+		b.bots["host"] = engine.BotRandom
+		return nil
+	})
+
+	if _, err := PlayBot(code); !errors.Is(err, ErrGameErrored) {
+		t.Fatalf("got %v, want ErrGameErrored", err)
+	}
+	if got := <-crashed; got != code {
+		t.Errorf("crash handler got %s, want %s", got, code)
+	}
+	if _, err := Snapshot(code); !errors.Is(err, ErrGameErrored) {
+		t.Errorf("got %v, want ErrGameErrored", err)
+	}
+}
@@ -0,0 +1,213 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"omgtant/claustroboard/shared/dtos"
+	"omgtant/claustroboard/shared/engine"
+	"omgtant/claustroboard/shared/enums"
+	"slices"
+	"strings"
+)
+
+// Limits and defaults of game configs.
+const (
+	ConfigVersion = 1 // Version of the configs this server writes
+
+	MinBoardSide   = 2
+	MaxBoardSide   = 16
+	MinPlayerCount = 2
+	MaxPlayerCount = 16
+	MaxEnergy      = 8
+	MinTurnTime    = 5    // Seconds
+	MaxTurnTime    = 3600 // Seconds
+
+	defaultBoardSide  = 4
+	defaultMaxPlayers = 4
+)
+
+var timeoutPolicies = []dtos.TimeoutPolicy{dtos.TimeoutRandom, dtos.TimeoutSkip, dtos.TimeoutEliminate}
+
+// configMigrations[v] upgrades a config of version v to version v+1.
+var configMigrations = []func(*dtos.GameConfig){
+	0: migrateUnversionedConfig,
+}
+
+// ConfigProblem is one reason a config was rejected.
+type ConfigProblem struct {
+	Field   string `json:"field"` // e.g. "deck[2].count"
+	Message string `json:"message"`
+}
+
+// ConfigError lists everything wrong with a config. It matches ErrInvalidConfig.
+type ConfigError struct {
+	Problems []ConfigProblem
+}
+
+func (e *ConfigError) Error() string {
+	msgs := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		msgs[i] = p.Field + ": " + p.Message
+	}
+	return ErrInvalidConfig.Error() + ": " + strings.Join(msgs, "; ")
+}
+
+func (e *ConfigError) Unwrap() error { return ErrInvalidConfig }
+
+func (e *ConfigError) add(field, format string, args ...any) {
+	e.Problems = append(e.Problems, ConfigProblem{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// NormalizeConfig migrates cfg to the current version, fills in the missing fields
+// and checks that a game can be played with it. Every problem found is reported at once.
+func NormalizeConfig(cfg dtos.GameConfig) (dtos.GameConfig, error) {
+	if cfg.Version < 0 || cfg.Version > ConfigVersion {
+		return cfg, &ConfigError{Problems: []ConfigProblem{{
+			Field:   "version",
+			Message: fmt.Sprintf("unsupported version %d, expected at most %d", cfg.Version, ConfigVersion),
+		}}}
+	}
+	cfg.Deck = slices.Clone(cfg.Deck)
+	for v := cfg.Version; v < ConfigVersion; v++ {
+		configMigrations[v](&cfg)
+	}
+	cfg.Version = ConfigVersion
+
+	if cfg.Width == 0 {
+		cfg.Width = defaultBoardSide
+	}
+	if cfg.Height == 0 {
+		cfg.Height = defaultBoardSide
+	}
+	if cfg.MaxPlayers == 0 {
+		cfg.MaxPlayers = min(defaultMaxPlayers, cfg.Width*cfg.Height)
+	}
+	if len(cfg.Deck) == 0 {
+		cfg.Deck = DefaultDeck()
+	}
+	if cfg.TimeoutPolicy == "" {
+		cfg.TimeoutPolicy = dtos.TimeoutRandom
+	}
+
+	errs := &ConfigError{}
+	if cfg.Width < MinBoardSide || cfg.Width > MaxBoardSide {
+		errs.add("width", "must be between %d and %d", MinBoardSide, MaxBoardSide)
+	}
+	if cfg.Height < MinBoardSide || cfg.Height > MaxBoardSide {
+		errs.add("height", "must be between %d and %d", MinBoardSide, MaxBoardSide)
+	}
+	if cfg.MaxPlayers < MinPlayerCount || cfg.MaxPlayers > MaxPlayerCount {
+		errs.add("maxPlayers", "must be between %d and %d", MinPlayerCount, MaxPlayerCount)
+	} else if cfg.MaxPlayers > cfg.Width*cfg.Height {
+		errs.add("maxPlayers", "%d players do not fit on %d tiles", cfg.MaxPlayers, cfg.Width*cfg.Height)
+	}
+	if cfg.TurnTimeLimit != 0 && (cfg.TurnTimeLimit < MinTurnTime || cfg.TurnTimeLimit > MaxTurnTime) {
+		errs.add("turnTimeLimit", "must be 0 or between %d and %d seconds", MinTurnTime, MaxTurnTime)
+	}
+	if !slices.Contains(timeoutPolicies, cfg.TimeoutPolicy) {
+		errs.add("timeoutPolicy", "unknown policy %q", cfg.TimeoutPolicy)
+	}
+	validateDeck(errs, cfg.Deck, cfg.Width*cfg.Height, cfg.MaxPlayers)
+
+	if len(errs.Problems) > 0 {
+		return cfg, errs
+	}
+	return cfg, nil
+}
+
+func validateDeck(errs *ConfigError, deck []dtos.TileConfig, boardSize, maxPlayers int) {
+	found := len(errs.Problems)
+	guaranteed, random := 0, false
+	// Tiles players may be placed on when the game starts
+	startable, randomStartable := 0, false
+	for i, tc := range deck {
+		field := fmt.Sprintf("deck[%d]", i)
+
+		kind, ok := enums.TileKindFromString(string(tc.Tile.Name))
+		if !ok {
+			errs.add(field+".tile.tile_type", "unknown tile %q", tc.Tile.Name)
+		}
+		if _, ok := enums.TileColorNames[tc.Tile.Color]; !ok && tc.Tile.Color != enums.UnspecifiedColor {
+			errs.add(field+".tile.color", "must be null or between %d and %d", enums.ColorLess, enums.Blue)
+		}
+		if ok && kind == enums.Layout {
+			var energy int
+			if err := json.Unmarshal(tc.Tile.Data["energy"], &energy); err != nil || energy < 1 || energy > MaxEnergy {
+				errs.add(field+".tile.data.energy", "must be between 1 and %d", MaxEnergy)
+			}
+		}
+
+		canStart := ok && engine.Tile{Kind: kind}.CanStart()
+		switch {
+		case tc.Count == dtos.UnspecifiedCount:
+			random = true
+			randomStartable = randomStartable || canStart
+		case tc.Count < 0:
+			errs.add(field+".count", "must be null or at least 0")
+		default:
+			guaranteed += int(tc.Count)
+			if canStart {
+				startable += int(tc.Count)
+			}
+		}
+	}
+
+	if guaranteed < boardSize && !random {
+		errs.add("deck", "%d tiles cannot fill the %d tiles of the board without random tiles", guaranteed, boardSize)
+	}
+	// Only worth checking once the deck itself is sound
+	if len(errs.Problems) == found && !randomStartable && startable < maxPlayers {
+		errs.add("deck", "%d players need a tile each to start on, the deck only has %d (layouts or wildcards)", maxPlayers, startable)
+	}
+}
+
+// migrateUnversionedConfig upgrades configs sent before versioning,
+// whose layouts stored their energy as move_count.
+func migrateUnversionedConfig(cfg *dtos.GameConfig) {
+	for i, tc := range cfg.Deck {
+		moveCount, ok := tc.Tile.Data["move_count"]
+		if !ok {
+			continue
+		}
+		data := make(map[string]json.RawMessage, len(tc.Tile.Data))
+		for k, v := range tc.Tile.Data {
+			if k != "move_count" {
+				data[k] = v
+			}
+		}
+		if _, ok := data["energy"]; !ok {
+			data["energy"] = moveCount
+		}
+		cfg.Deck[i].Tile.Data = data
+	}
+}
+
+// DefaultDeck is the deck of configs that come without one: the web client's
+// default, with random layouts to fill boards larger than it.
+func DefaultDeck() []dtos.TileConfig {
+	colors := []enums.TileColor{enums.Red, enums.Yellow, enums.Green, enums.Blue}
+	deck := []dtos.TileConfig{}
+	add := func(name enums.TileKindName, color enums.TileColor, data map[string]json.RawMessage, count int) {
+		deck = append(deck, dtos.TileConfig{
+			Tile:  dtos.BoardTile{Name: name, Color: color, Data: data},
+			Count: dtos.Count(count),
+		})
+	}
+
+	for energy := 1; energy <= 4; energy++ {
+		data := map[string]json.RawMessage{"energy": json.RawMessage(fmt.Sprint(energy))}
+		for _, color := range colors {
+			add(enums.TileKindNames[enums.Layout], color, data, 1)
+		}
+		add(enums.TileKindNames[enums.Layout], enums.UnspecifiedColor, data, int(dtos.UnspecifiedCount))
+	}
+	for _, color := range append([]enums.TileColor{enums.ColorLess}, colors...) {
+		add(enums.TileKindNames[enums.Teleport], color, nil, 1)
+	}
+	for _, color := range colors {
+		add(enums.TileKindNames[enums.Zero], color, nil, 1)
+		add(enums.TileKindNames[enums.Wall], color, nil, 2)
+	}
+	add(enums.TileKindNames[enums.Wildcard], enums.ColorLess, nil, 3)
+	return deck
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"omgtant/claustroboard/shared/dtos"
+	"slices"
+	"testing"
+)
+
+func parseConfig(t *testing.T, s string) dtos.GameConfig {
+	t.Helper()
+	var cfg dtos.GameConfig
+	if err := json.Unmarshal([]byte(s), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestNormalizeConfigDefaults(t *testing.T) {
+	cfg, err := NormalizeConfig(dtos.GameConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Version != ConfigVersion || cfg.Width != 4 || cfg.Height != 4 || cfg.MaxPlayers != 4 {
+		t.Errorf("defaults %+v", cfg)
+	}
+	if len(cfg.Deck) == 0 || cfg.TimeoutPolicy != dtos.TimeoutRandom {
+		t.Errorf("no default deck or timeout policy in %+v", cfg)
+	}
+}
+
+func TestNormalizeConfigMigratesUnversioned(t *testing.T) {
+	cfg, err := NormalizeConfig(parseConfig(t, `{"deck": [{"tile": {"tile_type": "Layout", "data": {"move_count": 2}}, "count": null}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(cfg.Deck[0].Tile.Data["energy"]); got != "2" {
+		t.Errorf("energy %q, want 2", got)
+	}
+}
+
+func TestNormalizeConfigProblems(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		fields []string
+	}{
+		{"future version", `{"version": 99}`, []string{"version"}},
+		{"board too small", `{"width": 1, "height": 40}`, []string{"width", "height"}},
+		{"too many players", `{"width": 2, "height": 2, "maxPlayers": 5}`, []string{"maxPlayers"}},
+		{"turn too short", `{"turnTimeLimit": 1}`, []string{"turnTimeLimit"}},
+		{"unknown policy", `{"timeoutPolicy": "pass"}`, []string{"timeoutPolicy"}},
+		{"bad tiles", `{"deck": [
+			{"tile": {"tile_type": "Lava"}, "count": null},
+			{"tile": {"tile_type": "Wall", "color": 7}, "count": null},
+			{"tile": {"tile_type": "Layout", "data": {"energy": 0}}, "count": -3}
+		]}`, []string{"deck[0].tile.tile_type", "deck[1].tile.color", "deck[2].tile.data.energy", "deck[2].count"}},
+		{"underfilled deck", `{"deck": [{"tile": {"tile_type": "Wall"}, "count": 3}]}`, []string{"deck"}},
+		{"nowhere to start", `{"deck": [{"tile": {"tile_type": "Wall"}, "count": null}]}`, []string{"deck"}},
+		{"too few starts", `{"maxPlayers": 3, "deck": [
+			{"tile": {"tile_type": "Wildcard"}, "count": 2},
+			{"tile": {"tile_type": "Teleport"}, "count": null}
+		]}`, []string{"deck"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NormalizeConfig(parseConfig(t, tt.config))
+			if !errors.Is(err, ErrInvalidConfig) {
+				t.Fatalf("error %v, want an invalid config", err)
+			}
+			var configErr *ConfigError
+			if !errors.As(err, &configErr) {
+				t.Fatalf("error %T is not a ConfigError", err)
+			}
+			var fields []string
+			for _, p := range configErr.Problems {
+				fields = append(fields, p.Field)
+			}
+			if !slices.Equal(fields, tt.fields) {
+				t.Errorf("problems with %v, want %v", fields, tt.fields)
+			}
+		})
+	}
+}
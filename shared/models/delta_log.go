@@ -0,0 +1,115 @@
+package models
+
+import (
+	"fmt"
+	"omgtant/claustroboard/shared/dtos"
+)
+
+// Resync returns what a client needs to catch up after the given turn:
+// the deltas applied since, or a full snapshot when since is nil or no longer logged.
+func Resync(code GameCode, since *uint32) (resync *dtos.Resync, err error) {
+	err = withBoard(code, func(b *Board) error {
+		resync, err = b.resync(since)
+		return err
+	})
+	return resync, err
+}
+
+func (b *Board) resync(since *uint32) (*dtos.Resync, error) {
+	if b.Phase == PhaseLobby {
+		return nil, ErrNotStarted
+	}
+
+	resync := &dtos.Resync{Turn: b.Game.CheckTurn}
+	if since != nil {
+		if *since > b.Game.CheckTurn {
+			return nil, fmt.Errorf("%w: turn %d is ahead of the game (turn %d)", ErrOutOfSync, *since, b.Game.CheckTurn)
+		}
+		if deltas, ok := b.deltasSince(*since); ok && b.logMatchesGame() {
+			resync.Deltas = deltas
+			return resync, nil
+		}
+	}
+	resync.Snapshot = b.snapshot()
+	return resync, nil
+}
+
+// logMatchesGame reports whether replaying the log yields the current game,
+// i.e. whether every change to the game was logged as a delta.
+func (b *Board) logMatchesGame() bool {
+	var last string
+	if b.Initial != nil {
+		last = b.Initial.Hash
+	}
+	if len(b.Log) > 0 {
+		last = b.Log[len(b.Log)-1].Hash
+	}
+	return last == b.Game.StateHash()
+}
+
+// deltasSince returns the logged deltas after the given turn,
+// or false if some of them are missing from the log.
+func (b *Board) deltasSince(turn uint32) ([]dtos.Delta, bool) {
+	if turn == b.Game.CheckTurn {
+		return []dtos.Delta{}, true
+	}
+	for i, d := range b.Log {
+		if d.Turn == turn+1 {
+			return append([]dtos.Delta(nil), b.Log[i:]...), true
+		}
+	}
+	return nil, false
+}
+
+// History returns the state the game started from and every delta applied since,
+// which lead to the current state. Before the game starts, or when the log does not
+// lead to the current state, it returns the current state and no deltas instead.
+func History(code GameCode) (state *dtos.Board, deltas []dtos.Delta, err error) {
+	err = withBoard(code, func(b *Board) error {
+		if b.Game != nil && b.Initial != nil && b.logMatchesGame() {
+			state = b.Initial
+			deltas = append([]dtos.Delta{}, b.Log...)
+			return nil
+		}
+		state = b.snapshot()
+		deltas = []dtos.Delta{}
+		return nil
+	})
+	return state, deltas, err
+}
+
+// CheckStateHash compares the state hash a client computed at the given turn
+// with the server's. It returns nil when they match, or a snapshot to resync
+// the client with otherwise.
+func CheckStateHash(code GameCode, turn uint32, hash string) (resync *dtos.Resync, err error) {
+	err = withBoard(code, func(b *Board) error {
+		if b.Phase == PhaseLobby {
+			return ErrNotStarted
+		}
+		if turn > b.Game.CheckTurn {
+			return fmt.Errorf("%w: turn %d is ahead of the game (turn %d)", ErrOutOfSync, turn, b.Game.CheckTurn)
+		}
+		// Hashes that are no longer known cannot be trusted either
+		if expected, ok := b.hashAt(turn); !ok || expected != hash {
+			resync = &dtos.Resync{Turn: b.Game.CheckTurn, Snapshot: b.snapshot()}
+		}
+		return nil
+	})
+	return resync, err
+}
+
+// hashAt returns the state hash of the game once the given turn was played.
+func (b *Board) hashAt(turn uint32) (string, bool) {
+	if turn == b.Game.CheckTurn {
+		return b.Game.StateHash(), true
+	}
+	if b.Initial != nil && b.Initial.Turn == turn {
+		return b.Initial.Hash, b.Initial.Hash != ""
+	}
+	for _, d := range b.Log {
+		if d.Turn == turn {
+			return d.Hash, d.Hash != ""
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,26 @@
+package models
+
+import "errors"
+
+// Errors callers can tell apart with errors.Is, e.g. to pick a status code.
+// Most are wrapped with details of what went wrong.
+var (
+	ErrGameNotFound   = errors.New("game not found")
+	ErrGameFull       = errors.New("game is full")
+	ErrAlreadyStarted = errors.New("game has already started")
+	ErrNotStarted     = errors.New("game has not started")
+	ErrNicknameTaken  = errors.New("a player with the same nickname already joined")
+	ErrInvalidToken   = errors.New("invalid reconnect token")
+	ErrInvalidConfig  = errors.New("invalid config")
+	ErrNotHost        = errors.New("only the host can do that")
+	ErrNoSuchPlayer   = errors.New("no such player")
+	ErrNotAllowed     = errors.New("not allowed")
+	ErrNotYourTurn    = errors.New("it is not your turn")
+	ErrInvalidMove    = errors.New("invalid move")
+	ErrOutOfSync      = errors.New("out of sync")
+	ErrReplayNotFound = errors.New("replay not found")
+	ErrDeckNotFound   = errors.New("deck not found")
+	ErrDeckExists     = errors.New("a deck with the same name already exists")
+	ErrDeckLimit      = errors.New("the deck library is full")
+	ErrGameErrored    = errors.New("game stopped after an internal error")
+)
@@ -10,10 +10,12 @@ const alphabet = "2356789bcdfghjkmnpqrstwxyz"
 const alphLen = uint64(len(alphabet))
 const gameCodeLen = 5
 
-func RandomGameCode() GameCode {
+// RandomGameCode draws a code from rng. Codes do not come from the board's
+// own rng, so that retrying on a collision leaves the board layout untouched.
+func RandomGameCode(rng *rand.Rand) GameCode {
 	var result string
 	for range gameCodeLen {
-		result += string(alphabet[rand.Intn(int(alphLen))])
+		result += string(alphabet[rng.Intn(int(alphLen))])
 	}
 	return GameCode(result)
 }
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+)
+
+// CheckHost fails unless nickname is the host of the board.
+func CheckHost(code GameCode, nickname string) error {
+	return withBoard(code, func(b *Board) error {
+		return b.checkHost(nickname)
+	})
+}
+
+// Host returns the nickname of the host of the board.
+func Host(code GameCode) (host string, err error) {
+	err = withBoard(code, func(b *Board) error {
+		host = b.Host
+		return nil
+	})
+	return host, err
+}
+
+// TransferHost hands the host role from one seated player to another.
+func TransferHost(code GameCode, from, to string) error {
+	return withBoard(code, func(b *Board) error {
+		if err := b.checkHost(from); err != nil {
+			return err
+		}
+		if !b.isSeated(to) {
+			return ErrNoSuchPlayer
+		}
+		if b.IsBot(to) {
+			return fmt.Errorf("%w: bots cannot host", ErrNotAllowed)
+		}
+		b.Host = to
+		b.persist()
+		return nil
+	})
+}
+
+// Kick makes a player leave the board on behalf of the host.
+func Kick(code GameCode, by, target string) (d Departure, err error) {
+	err = withBoard(code, func(b *Board) error {
+		if err := b.checkHost(by); err != nil {
+			return err
+		}
+		if by == target {
+			return fmt.Errorf("%w: cannot kick yourself", ErrNotAllowed)
+		}
+		if !b.isSeated(target) {
+			return ErrNoSuchPlayer
+		}
+		d = b.leave(target)
+		return nil
+	})
+	return d, err
+}
+
+func (b *Board) checkHost(nickname string) error {
+	if b.Host != nickname {
+		return ErrNotHost
+	}
+	return nil
+}
+
+func (b *Board) isSeated(nickname string) bool {
+	return slices.Contains(b.Players, nickname) && !b.left[nickname]
+}
+
+// migrateHost hands the host role to the longest-seated remaining player.
+func (b *Board) migrateHost() {
+	for _, p := range b.Players {
+		if p != b.Host && !b.left[p] && !b.IsBot(p) {
+			b.Host = p
+			return
+		}
+	}
+	b.Host = ""
+}
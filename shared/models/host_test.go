@@ -0,0 +1,99 @@
+package models
+
+import (
+	"errors"
+	"omgtant/claustroboard/shared/dtos"
+	"omgtant/claustroboard/shared/engine"
+	"testing"
+)
+
+func TestHostMigratesWhenTheHostLeaves(t *testing.T) {
+	UseRepository(NewMemoryRepository())
+	code, err := NewGameBoard([]string{"a"}, dtos.GameConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AddBot(code, "a", engine.BotRandom); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{"b", "c"} {
+		if err := Join(code, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Bots are skipped, the longest-seated player hosts next
+	d, err := Leave(code, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if host, _ := Host(code); !d.WasHost || host != "b" {
+		t.Errorf("host %q after the host left (%+v), want b", host, d)
+	}
+	if d, _ := Leave(code, "c"); d.WasHost {
+		t.Error("a guest leaving moved the host role")
+	}
+	if host, _ := Host(code); host != "b" {
+		t.Errorf("host %q after a guest left, want b", host)
+	}
+}
+
+func TestOnlyTheHostManagesTheLobby(t *testing.T) {
+	UseRepository(NewMemoryRepository())
+	code, err := NewGameBoard([]string{"a", "b", "c"}, dtos.GameConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Kick(code, "b", "c"); !errors.Is(err, ErrNotHost) {
+		t.Errorf("kick by a guest: got %v, want ErrNotHost", err)
+	}
+	if err := TransferHost(code, "b", "c"); !errors.Is(err, ErrNotHost) {
+		t.Errorf("transfer by a guest: got %v, want ErrNotHost", err)
+	}
+	players, _, _ := PlayerList(code)
+	if host, _ := Host(code); host != "a" || len(players) != 3 {
+		t.Fatalf("host %q with players %v after refused actions", host, players)
+	}
+
+	if err := TransferHost(code, "a", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Kick(code, "a", "c"); !errors.Is(err, ErrNotHost) {
+		t.Errorf("kick by the former host: got %v, want ErrNotHost", err)
+	}
+	if _, err := Kick(code, "b", "c"); err != nil {
+		t.Errorf("kick by the new host: %v", err)
+	}
+}
+
+func TestKickDuringTheGame(t *testing.T) {
+	UseRepository(NewMemoryRepository())
+	seed := int64(7)
+	code, err := NewGameBoard([]string{"a", "b", "c"}, dtos.GameConfig{Width: 6, Height: 6, Seed: &seed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, _, err := StartGame(code, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	target := state.Players[state.Current].Name
+	if target == "a" {
+		target = state.Players[(state.Current+1)%len(state.Players)].Name
+	}
+	d, err := Kick(code, "a", target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.WasStarted || d.Delta == nil || d.Delta.Forfeit == nil || state.Players[*d.Delta.Forfeit].Name != target {
+		t.Fatalf("kicking %s: departure %+v", target, d)
+	}
+	if d.Result != nil {
+		t.Errorf("game over with two players left: %+v", d.Result)
+	}
+	if _, err := Kick(code, "a", target); !errors.Is(err, ErrNoSuchPlayer) {
+		t.Errorf("kicking %s again: got %v, want ErrNoSuchPlayer", target, err)
+	}
+}
@@ -0,0 +1,141 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"omgtant/claustroboard/shared/dtos"
+	"sync/atomic"
+	"time"
+)
+
+type JanitorConfig struct {
+	IdleTimeout time.Duration // Boards without connected clients are removed after this long
+	Retention   time.Duration // Finished games are removed this long after they end
+	Interval    time.Duration // Time between two sweeps, defaults to a minute
+
+	// Connected reports whether the board has any connected client.
+	Connected func(GameCode) bool
+	// Evicted is called after a board was removed, e.g. to close its remaining clients.
+	Evicted func(GameCode)
+}
+
+var evictedBoards atomic.Uint64
+
+// RunJanitor removes abandoned and finished boards from the registry until ctx is done.
+func RunJanitor(ctx context.Context, cfg JanitorConfig) {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Minute
+	}
+	t := time.NewTicker(cfg.Interval)
+	defer t.Stop()
+
+	lastSeen := make(map[GameCode]time.Time)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			for _, code := range sweep(cfg, lastSeen, now) {
+				if cfg.Evicted != nil {
+					cfg.Evicted(code)
+				}
+			}
+		}
+	}
+}
+
+// sweep evicts the boards that expired at now, and returns their codes.
+// lastSeen remembers when each board last had a client.
+func sweep(cfg JanitorConfig, lastSeen map[GameCode]time.Time, now time.Time) (evicted []GameCode) {
+	boards := make(map[GameCode]*Board)
+	for _, b := range allBoards() {
+		boards[b.Code] = b
+	}
+
+	for code := range lastSeen {
+		if _, ok := boards[code]; !ok {
+			delete(lastSeen, code)
+		}
+	}
+
+	for code, b := range boards {
+		if _, ok := lastSeen[code]; !ok || cfg.Connected == nil || cfg.Connected(code) {
+			lastSeen[code] = now
+		}
+
+		idle := now.Sub(lastSeen[code]) >= cfg.IdleTimeout
+		finished := false
+		err := b.execTimeout(func() error {
+			finished = b.Phase == PhaseFinished && now.Sub(b.FinishedAt) >= cfg.Retention
+			return nil
+		}, boardAnswerTimeout)
+		// Boards that do not answer are checked again on the next sweep
+		expired := err == nil && finished
+		// Errored boards refuse every command, there is nothing left to keep them for
+		if errors.Is(err, ErrGameErrored) {
+			expired = true
+		}
+		if idle || expired {
+			evicted = append(evicted, code)
+		}
+	}
+
+	gameBoardsMu.Lock()
+	for _, code := range evicted {
+		delete(gameBoards, code)
+	}
+	gameBoardsMu.Unlock()
+
+	for _, code := range evicted {
+		boards[code].stop()
+		delete(lastSeen, code)
+		forget(code)
+		evictedBoards.Add(1)
+		notifyLobby(code, nil)
+		fmt.Printf("Evicted board %s\n", code)
+	}
+	return evicted
+}
+
+// BoardStats counts the boards in the registry and those evicted since startup.
+func BoardStats() dtos.BoardStats {
+	gameBoardsMu.RLock()
+	live := len(gameBoards)
+	gameBoardsMu.RUnlock()
+
+	return dtos.BoardStats{
+		Live:    live,
+		Evicted: evictedBoards.Load(),
+	}
+}
+
+// StartedGames lists the boards with a game in progress, leaving out those that
+// do not answer in time.
+func StartedGames() []GameCode {
+	var codes []GameCode
+	for _, b := range allBoards() {
+		started := false
+		err := b.execTimeout(func() error {
+			started = b.Phase == PhaseStarted
+			return nil
+		}, boardAnswerTimeout)
+		if err == nil && started {
+			codes = append(codes, b.Code)
+		}
+	}
+	return codes
+}
+
+// Shutdown saves every board and stops its actor, so that nothing changes after
+// the last save. Commands sent afterwards fail with ErrGameNotFound. Boards that
+// do not answer in time are stopped without a final save.
+func Shutdown() (saved int) {
+	for _, b := range allBoards() {
+		if b.execTimeout(func() error { b.persist(); return nil }, boardAnswerTimeout) == nil {
+			saved++
+		}
+		b.stop()
+	}
+	return saved
+}
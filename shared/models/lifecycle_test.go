@@ -0,0 +1,80 @@
+package models
+
+import (
+	"errors"
+	"omgtant/claustroboard/shared/dtos"
+	"testing"
+	"time"
+)
+
+func TestSweep(t *testing.T) {
+	cfg := JanitorConfig{IdleTimeout: 10 * time.Minute, Retention: time.Hour}
+	now := time.Now()
+
+	cases := []struct {
+		name      string
+		idleFor   time.Duration // Since a client was last seen
+		connected bool
+		finished  time.Duration // Since the game ended, if it did
+		crash     bool
+		evicted   bool
+	}{
+		{name: "active lobby", idleFor: time.Minute},
+		{name: "idle lobby", idleFor: 11 * time.Minute, evicted: true},
+		{name: "idle but connected", idleFor: 11 * time.Minute, connected: true},
+		{name: "finished within retention", connected: true, finished: 30 * time.Minute},
+		{name: "finished past retention", connected: true, finished: 2 * time.Hour, evicted: true},
+		{name: "errored", connected: true, crash: true, evicted: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := NewMemoryRepository()
+			UseRepository(repo)
+			changes, cancel := SubscribeLobbies()
+			defer cancel()
+
+			code, err := NewGameBoard([]string{"host"}, dtos.GameConfig{Public: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.finished > 0 {
+				_ = withBoard(code, func(b *Board) error {
+					b.Phase = PhaseFinished
+					b.FinishedAt = now.Add(-tc.finished)
+					return nil
+				})
+			}
+			if tc.crash {
+				_ = withBoard(code, func(b *Board) error {
+					panic("test crash")
+				})
+			}
+			<-changes // The board was listed
+
+			cfg.Connected = func(c GameCode) bool { return c == code && tc.connected }
+			lastSeen := map[GameCode]time.Time{code: now.Add(-tc.idleFor)}
+			evicted := false
+			for _, c := range sweep(cfg, lastSeen, now) {
+				evicted = evicted || c == code
+			}
+			if evicted != tc.evicted {
+				t.Fatalf("evicted %v, want %v", evicted, tc.evicted)
+			}
+
+			_, err = getBoard(code)
+			repo.mu.Lock()
+			_, stored := repo.boards[code]
+			repo.mu.Unlock()
+			if tc.evicted {
+				if !errors.Is(err, ErrGameNotFound) || stored {
+					t.Errorf("evicted board still registered (%v) or stored (%v)", err, stored)
+				}
+				if change := <-changes; change.Code != code.String() || change.Type != dtos.LobbyRemoved {
+					t.Errorf("lobby change %+v, want %s removed", change, code)
+				}
+			} else if err != nil || !stored {
+				t.Errorf("kept board missing from the registry (%v) or the repository", err)
+			}
+		})
+	}
+}
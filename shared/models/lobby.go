@@ -0,0 +1,115 @@
+package models
+
+import (
+	"omgtant/claustroboard/shared/dtos"
+	"omgtant/claustroboard/shared/enums"
+	"slices"
+	"sort"
+	"sync"
+)
+
+var (
+	lobbiesMu        sync.Mutex
+	listedLobbies    = make(map[GameCode]dtos.Lobby) // Latest listing of each listed board
+	lobbySubscribers = make(map[chan dtos.LobbyChange]struct{})
+)
+
+// ListLobbies returns every public board still waiting for players.
+// Listings are kept up to date by notifyLobby, so that no board is waited on.
+func ListLobbies() []dtos.Lobby {
+	lobbiesMu.Lock()
+	lobbies := make([]dtos.Lobby, 0, len(listedLobbies))
+	for _, lobby := range listedLobbies {
+		lobbies = append(lobbies, lobby)
+	}
+	lobbiesMu.Unlock()
+
+	sort.Slice(lobbies, func(i, j int) bool { return lobbies[i].Code < lobbies[j].Code })
+	return lobbies
+}
+
+// SubscribeLobbies streams the changes to ListLobbies until cancel is called.
+// Changes are dropped for subscribers that do not keep up.
+func SubscribeLobbies() (changes <-chan dtos.LobbyChange, cancel func()) {
+	ch := make(chan dtos.LobbyChange, 32)
+
+	lobbiesMu.Lock()
+	lobbySubscribers[ch] = struct{}{}
+	lobbiesMu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			lobbiesMu.Lock()
+			delete(lobbySubscribers, ch)
+			lobbiesMu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// notifyLobby tells subscribers how the board's listing changed, if it did.
+// lobby is the new listing, nil when the board is not or no longer listed.
+func notifyLobby(code GameCode, lobby *dtos.Lobby) {
+	lobbiesMu.Lock()
+	defer lobbiesMu.Unlock()
+
+	change := dtos.LobbyChange{Code: code.String()}
+	_, listed := listedLobbies[code]
+	switch {
+	case lobby != nil:
+		change.Lobby = lobby
+		change.Type = dtos.LobbyChanged
+		if !listed {
+			change.Type = dtos.LobbyAdded
+		}
+		listedLobbies[code] = *lobby
+	case listed:
+		change.Type = dtos.LobbyRemoved
+		delete(listedLobbies, code)
+	default:
+		return
+	}
+
+	for ch := range lobbySubscribers {
+		select {
+		case ch <- change:
+		default:
+		}
+	}
+}
+
+// listing returns the board as shown in the lobby browser, or nil while it is not listed.
+func (b *Board) listing() *dtos.Lobby {
+	if !b.Public || b.Phase != PhaseLobby {
+		return nil
+	}
+	return &dtos.Lobby{
+		Code:       b.Code.String(),
+		Players:    slices.Clone(b.Players),
+		MaxPlayers: int(b.MaxPlayers),
+		Width:      b.Width,
+		Height:     b.Height,
+		Deck:       summarizeDeck(b.Deck),
+	}
+}
+
+// summarizeDeck counts the guaranteed tiles of each kind, in the order kinds first appear.
+func summarizeDeck(deck []dtos.TileConfig) []dtos.DeckSummary {
+	summary := []dtos.DeckSummary{}
+	index := make(map[enums.TileKindName]int)
+	for _, tc := range deck {
+		i, ok := index[tc.Tile.Name]
+		if !ok {
+			i = len(summary)
+			index[tc.Tile.Name] = i
+			summary = append(summary, dtos.DeckSummary{Name: tc.Tile.Name})
+		}
+		if tc.Count == dtos.UnspecifiedCount {
+			summary[i].Random = true
+		} else if tc.Count > 0 {
+			summary[i].Count += int(tc.Count)
+		}
+	}
+	return summary
+}
@@ -0,0 +1,135 @@
+package models
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"omgtant/claustroboard/shared/dtos"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+//go:embed presets.json
+var builtinPresetsJSON []byte
+
+// builtinPresets ship with the server, normalized.
+var builtinPresets = loadBuiltinPresets()
+
+var presetNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+// Limits of the presets saved by players.
+const (
+	MaxSavedDecks         = 1000
+	MaxDeckDescription    = 280 // Characters
+	maxSavedDeckTileKinds = MaxBoardSide * MaxBoardSide
+)
+
+func loadBuiltinPresets() []dtos.DeckPreset {
+	var presets []dtos.DeckPreset
+	if err := json.Unmarshal(builtinPresetsJSON, &presets); err != nil {
+		panic(fmt.Sprintf("models: invalid builtin presets: %v", err))
+	}
+	for i := range presets {
+		cfg, err := NormalizeConfig(presets[i].Config)
+		if err != nil {
+			panic(fmt.Sprintf("models: builtin preset %s: %v", presets[i].Name, err))
+		}
+		presets[i].Config = cfg
+		presets[i].Builtin = true
+	}
+	return presets
+}
+
+// ListDecks returns the builtin presets followed by the saved ones, by name.
+func ListDecks() ([]dtos.DeckPreset, error) {
+	repoMu.RLock()
+	r := repo
+	repoMu.RUnlock()
+
+	saved, err := r.LoadDecks()
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(saved, func(a, b dtos.DeckPreset) int { return strings.Compare(a.Name, b.Name) })
+	return append(slices.Clone(builtinPresets), saved...), nil
+}
+
+// GetDeck returns the preset with the given name.
+func GetDeck(name string) (*dtos.DeckPreset, error) {
+	for _, p := range builtinPresets {
+		if p.Name == name {
+			return &p, nil
+		}
+	}
+
+	repoMu.RLock()
+	r := repo
+	repoMu.RUnlock()
+
+	return r.LoadDeck(name)
+}
+
+// SaveDeck validates and stores a preset made by a player. Names are
+// lowercase letters, digits and dashes, and cannot be reused.
+func SaveDeck(preset dtos.DeckPreset) (*dtos.DeckPreset, error) {
+	if !presetNamePattern.MatchString(preset.Name) {
+		return nil, &ConfigError{Problems: []ConfigProblem{{
+			Field:   "name",
+			Message: "must be 1 to 32 lowercase letters, digits or dashes",
+		}}}
+	}
+	if n := utf8.RuneCountInString(preset.Description); n > MaxDeckDescription {
+		return nil, &ConfigError{Problems: []ConfigProblem{{
+			Field:   "description",
+			Message: fmt.Sprintf("must be at most %d characters", MaxDeckDescription),
+		}}}
+	}
+	if len(preset.Config.Deck) > maxSavedDeckTileKinds {
+		return nil, &ConfigError{Problems: []ConfigProblem{{
+			Field:   "config.deck",
+			Message: fmt.Sprintf("must have at most %d entries", maxSavedDeckTileKinds),
+		}}}
+	}
+	cfg, err := NormalizeConfig(preset.Config)
+	if err != nil {
+		return nil, err
+	}
+	preset.Config = cfg
+	// Seeds and settings of the game itself are chosen when creating it
+	preset.Config.Seed = nil
+	preset.Config.Public = false
+	preset.Builtin = false
+
+	for _, p := range builtinPresets {
+		if p.Name == preset.Name {
+			return nil, fmt.Errorf("%w: %s", ErrDeckExists, preset.Name)
+		}
+	}
+
+	// The repository refuses names already saved, and decks past the limit
+	repoMu.RLock()
+	r := repo
+	repoMu.RUnlock()
+
+	if err := r.SaveDeck(preset, MaxSavedDecks); err != nil {
+		return nil, err
+	}
+	return &preset, nil
+}
+
+// PresetConfig returns a copy of the config of a preset, which a partial
+// config in JSON can be decoded onto to override some of its fields.
+func PresetConfig(name string) (dtos.GameConfig, error) {
+	preset, err := GetDeck(name)
+	if err != nil {
+		return dtos.GameConfig{}, err
+	}
+
+	cfg := preset.Config
+	// Decoding reuses slices and pointers, which must not be shared with the preset
+	cfg.Deck = slices.Clone(cfg.Deck)
+	cfg.Seed = nil
+	return cfg, nil
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"omgtant/claustroboard/shared/dtos"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPresetConfigOverrides(t *testing.T) {
+	cfg, err := PresetConfig("big-chaotic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	overrides := `{"width": 6, "deck": [{"tile": {"tile_type": "Zero"}, "count": null}]}`
+	if err := json.Unmarshal([]byte(overrides), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Width != 6 || cfg.Height != 8 || len(cfg.Deck) != 1 {
+		t.Errorf("overridden config %+v", cfg)
+	}
+
+	preset, _ := GetDeck("big-chaotic")
+	if preset.Config.Width != 8 || preset.Config.Deck[0].Tile.Name != "Layout" {
+		t.Error("overriding changed the preset")
+	}
+
+	if _, err := PresetConfig("nope"); !errors.Is(err, ErrDeckNotFound) {
+		t.Errorf("error %v, want a missing deck", err)
+	}
+}
+
+func TestSaveDeck(t *testing.T) {
+	if _, err := UseRepository(NewMemoryRepository()); err != nil {
+		t.Fatal(err)
+	}
+
+	preset := dtos.DeckPreset{Name: "layouts-only", Config: dtos.GameConfig{
+		Deck: []dtos.TileConfig{{
+			Tile:  dtos.BoardTile{Name: "Layout", Data: map[string]json.RawMessage{"energy": json.RawMessage("1")}},
+			Count: dtos.UnspecifiedCount,
+		}},
+	}}
+	saved, err := SaveDeck(preset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Builtin || saved.Config.Width != 4 {
+		t.Errorf("saved %+v", saved)
+	}
+	if got, err := GetDeck("layouts-only"); err != nil || got.Name != "layouts-only" {
+		t.Errorf("GetDeck: %v, %v", got, err)
+	}
+
+	if _, err := SaveDeck(preset); !errors.Is(err, ErrDeckExists) {
+		t.Errorf("error %v, want an existing deck", err)
+	}
+	preset.Name = "classic"
+	if _, err := SaveDeck(preset); !errors.Is(err, ErrDeckExists) {
+		t.Errorf("error %v, want an existing deck", err)
+	}
+	preset.Name = "too-long"
+	preset.Description = strings.Repeat("a", MaxDeckDescription+1)
+	if _, err := SaveDeck(preset); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("error %v, want an invalid config", err)
+	}
+	preset.Description = ""
+	preset.Name = "Not a name!"
+	if _, err := SaveDeck(preset); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("error %v, want an invalid config", err)
+	}
+
+	decks, err := ListDecks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decks) != len(builtinPresets)+1 || decks[len(decks)-1].Name != "layouts-only" {
+		t.Errorf("decks %v", decks)
+	}
+}
+
+func TestSaveDeckConcurrently(t *testing.T) {
+	sqliteRepo, err := NewSQLiteRepository(filepath.Join(t.TempDir(), "db.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqliteRepo.Close()
+	defer UseRepository(NewMemoryRepository())
+
+	for name, r := range map[string]BoardRepository{"memory": NewMemoryRepository(), "sqlite": sqliteRepo} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := UseRepository(r); err != nil {
+				t.Fatal(err)
+			}
+
+			// Only one of the saves under the same name goes through
+			errs := make(chan error, 8)
+			for i := range 8 {
+				go func() {
+					_, err := SaveDeck(dtos.DeckPreset{Name: "mine", Description: fmt.Sprint(i)})
+					errs <- err
+				}()
+			}
+			saved := 0
+			for range 8 {
+				if err := <-errs; err == nil {
+					saved++
+				} else if !errors.Is(err, ErrDeckExists) {
+					t.Error(err)
+				}
+			}
+			if saved != 1 {
+				t.Errorf("%d saves went through", saved)
+			}
+			if err := r.SaveDeck(dtos.DeckPreset{Name: "other"}, 1); !errors.Is(err, ErrDeckLimit) {
+				t.Errorf("error %v past the limit, want ErrDeckLimit", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,366 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"omgtant/claustroboard/shared/dtos"
+	"omgtant/claustroboard/shared/engine"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BoardRepository persists boards so that lobbies and matches survive a restart.
+type BoardRepository interface {
+	SaveBoard(r BoardRecord) error
+	DeleteBoard(code GameCode) error
+	LoadBoards() ([]BoardRecord, error)
+	// SaveReplay archives a finished game, kept after its board is deleted.
+	SaveReplay(code GameCode, r dtos.Replay) error
+	// LoadReplay returns the last finished game of a board.
+	LoadReplay(code GameCode) (*dtos.Replay, error)
+	// SaveDeck stores a preset saved by a player, or fails with ErrDeckExists when
+	// its name is taken and ErrDeckLimit when limit presets are already stored.
+	// LoadDeck and LoadDecks return them.
+	SaveDeck(p dtos.DeckPreset, limit int) error
+	LoadDeck(name string) (*dtos.DeckPreset, error)
+	LoadDecks() ([]dtos.DeckPreset, error)
+	Close() error
+}
+
+// BoardRecord is the persisted form of a board.
+type BoardRecord struct {
+	Code  GameCode
+	Phase BoardPhase
+	Seats []SeatRecord
+	Log   []dtos.Delta
+	State json.RawMessage // Everything else, see boardRecordState
+}
+
+// SeatRecord is a player of a persisted board, in turn order.
+type SeatRecord struct {
+	Nickname string
+	Token    string
+	Left     bool
+}
+
+type boardRecordState struct {
+	Width      uint16                     `json:"width"`
+	Height     uint16                     `json:"height"`
+	MaxPlayers uint8                      `json:"maxPlayers"`
+	Public     bool                       `json:"public"`
+	Deck       []dtos.TileConfig          `json:"deck"`
+	Host       string                     `json:"host"`
+	Seed       int64                      `json:"seed"`
+	Game       *engine.Game               `json:"game,omitempty"`
+	Result     *dtos.GameResult           `json:"result,omitempty"`
+	FinishedAt time.Time                  `json:"finishedAt"`
+	Initial    *dtos.Board                `json:"initial,omitempty"`
+	Bots       map[string]engine.BotLevel `json:"bots,omitempty"`
+
+	TurnTimeLimit time.Duration      `json:"turnTimeLimit,omitempty"`
+	TimeoutPolicy dtos.TimeoutPolicy `json:"timeoutPolicy,omitempty"`
+	TurnDeadline  time.Time          `json:"turnDeadline"`
+}
+
+var (
+	repoMu sync.RWMutex
+	repo   BoardRepository = NewMemoryRepository()
+)
+
+// OpenRepository picks the repository matching a connection string:
+// in-memory when it is empty or "memory", an SQLite file otherwise.
+func OpenRepository(connectionString string) (BoardRepository, error) {
+	switch strings.TrimSpace(connectionString) {
+	case "", "memory":
+		return NewMemoryRepository(), nil
+	default:
+		return NewSQLiteRepository(connectionString)
+	}
+}
+
+// UseRepository makes the registry persist boards to r and loads the boards r holds.
+func UseRepository(r BoardRepository) (restored int, err error) {
+	records, err := r.LoadBoards()
+	if err != nil {
+		return 0, err
+	}
+
+	repoMu.Lock()
+	repo = r
+	repoMu.Unlock()
+
+	for _, rec := range records {
+		b, err := boardFromRecord(rec)
+		if err != nil {
+			fmt.Printf("Skipping stored board %s: %v\n", rec.Code, err)
+			continue
+		}
+		// The actor is not running yet, nothing else can touch the board
+		notifyLobby(rec.Code, b.listing())
+
+		gameBoardsMu.Lock()
+		gameBoards[rec.Code] = b
+		b.start()
+		gameBoardsMu.Unlock()
+		restored++
+	}
+	return restored, nil
+}
+
+// persist saves the board, from a command on its actor.
+// Storage errors are logged, the in-memory board stays authoritative.
+func (b *Board) persist() {
+	repoMu.RLock()
+	r := repo
+	repoMu.RUnlock()
+
+	rec, err := b.record()
+	if err == nil {
+		err = r.SaveBoard(rec)
+	}
+	if err != nil {
+		fmt.Printf("Failed to save board %s: %v\n", b.Code, err)
+	}
+}
+
+// archive saves the replay of the game that just finished.
+func (b *Board) archive() {
+	repoMu.RLock()
+	r := repo
+	repoMu.RUnlock()
+
+	replay := dtos.Replay{
+		Code:       b.Code.String(),
+		Config:     b.config(),
+		Initial:    b.Initial,
+		Deltas:     append([]dtos.Delta{}, b.Log...),
+		Result:     b.Result,
+		FinishedAt: b.FinishedAt,
+	}
+	if err := r.SaveReplay(b.Code, replay); err != nil {
+		fmt.Printf("Failed to archive game %s: %v\n", b.Code, err)
+	}
+}
+
+// GetReplay returns the archive of the last game finished on a board.
+func GetReplay(code GameCode) (*dtos.Replay, error) {
+	repoMu.RLock()
+	r := repo
+	repoMu.RUnlock()
+
+	return r.LoadReplay(code)
+}
+
+func forget(code GameCode) {
+	repoMu.RLock()
+	r := repo
+	repoMu.RUnlock()
+
+	if err := r.DeleteBoard(code); err != nil {
+		fmt.Printf("Failed to delete board %s: %v\n", code, err)
+	}
+}
+
+func (b *Board) record() (BoardRecord, error) {
+	state, err := json.Marshal(boardRecordState{
+		Width:      b.Width,
+		Height:     b.Height,
+		MaxPlayers: b.MaxPlayers,
+		Public:     b.Public,
+		Deck:       b.Deck,
+		Host:       b.Host,
+		Seed:       b.Seed,
+		Game:       b.Game,
+		Result:     b.Result,
+		FinishedAt: b.FinishedAt,
+		Initial:    b.Initial,
+		Bots:       b.bots,
+
+		TurnTimeLimit: b.TurnTimeLimit,
+		TimeoutPolicy: b.TimeoutPolicy,
+		TurnDeadline:  b.TurnDeadline,
+	})
+	if err != nil {
+		return BoardRecord{}, err
+	}
+
+	seats := make([]SeatRecord, len(b.Players))
+	for i, p := range b.Players {
+		seats[i] = SeatRecord{
+			Nickname: p,
+			Token:    b.tokens[p],
+			Left:     b.left[p],
+		}
+	}
+
+	return BoardRecord{
+		Code:  b.Code,
+		Phase: b.Phase,
+		Seats: seats,
+		Log:   append([]dtos.Delta{}, b.Log...),
+		State: state,
+	}, nil
+}
+
+func boardFromRecord(rec BoardRecord) (*Board, error) {
+	var state boardRecordState
+	if err := json.Unmarshal(rec.State, &state); err != nil {
+		return nil, err
+	}
+	if rec.Phase != PhaseLobby {
+		if state.Game == nil {
+			return nil, fmt.Errorf("%s board has no game", rec.Phase)
+		}
+		if len(state.Game.Tiles) != int(state.Game.Height) {
+			return nil, fmt.Errorf("expected %d rows of tiles, got %d", state.Game.Height, len(state.Game.Tiles))
+		}
+		if len(state.Game.Pos) != len(state.Game.Players) {
+			return nil, fmt.Errorf("%d players but %d positions", len(state.Game.Players), len(state.Game.Pos))
+		}
+	}
+
+	b := &Board{
+		Code:       rec.Code,
+		Width:      state.Width,
+		Height:     state.Height,
+		MaxPlayers: state.MaxPlayers,
+		Public:     state.Public,
+		Deck:       state.Deck,
+		Host:       state.Host,
+		Seed:       state.Seed,
+		Game:       state.Game,
+		Log:        rec.Log,
+		Result:     state.Result,
+		FinishedAt: state.FinishedAt,
+		Initial:    state.Initial,
+		Phase:      rec.Phase,
+		bots:       state.Bots,
+
+		TurnTimeLimit: state.TurnTimeLimit,
+		TimeoutPolicy: state.TimeoutPolicy,
+		TurnDeadline:  state.TurnDeadline,
+
+		tokens: make(map[string]string),
+		left:   make(map[string]bool),
+	}
+	for _, s := range rec.Seats {
+		b.Players = append(b.Players, s.Nickname)
+		if s.Token != "" {
+			b.tokens[s.Nickname] = s.Token
+		}
+		if s.Left {
+			b.left[s.Nickname] = true
+		}
+	}
+	return b, nil
+}
+
+// How many replays a MemoryRepository keeps, the oldest are dropped first.
+const maxMemoryReplays = 1000
+
+// MemoryRepository keeps boards for the lifetime of the process only.
+type MemoryRepository struct {
+	mu          sync.Mutex
+	boards      map[GameCode]BoardRecord
+	replays     map[GameCode]dtos.Replay
+	replayOrder []GameCode // Codes of replays, oldest first
+	decks       map[string]dtos.DeckPreset
+}
+
+var _ BoardRepository = &MemoryRepository{}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		boards:  make(map[GameCode]BoardRecord),
+		replays: make(map[GameCode]dtos.Replay),
+		decks:   make(map[string]dtos.DeckPreset),
+	}
+}
+
+func (m *MemoryRepository) SaveBoard(r BoardRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.boards[r.Code] = r
+	return nil
+}
+
+func (m *MemoryRepository) DeleteBoard(code GameCode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.boards, code)
+	return nil
+}
+
+func (m *MemoryRepository) LoadBoards() ([]BoardRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	records := make([]BoardRecord, 0, len(m.boards))
+	for _, r := range m.boards {
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+func (m *MemoryRepository) SaveReplay(code GameCode, r dtos.Replay) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.replays[code]; ok {
+		m.replayOrder = slices.DeleteFunc(m.replayOrder, func(c GameCode) bool { return c == code })
+	}
+	m.replays[code] = r
+	m.replayOrder = append(m.replayOrder, code)
+	if len(m.replayOrder) > maxMemoryReplays {
+		delete(m.replays, m.replayOrder[0])
+		m.replayOrder = m.replayOrder[1:]
+	}
+	return nil
+}
+
+func (m *MemoryRepository) LoadReplay(code GameCode) (*dtos.Replay, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, ok := m.replays[code]
+	if !ok {
+		return nil, ErrReplayNotFound
+	}
+	return &r, nil
+}
+
+func (m *MemoryRepository) SaveDeck(p dtos.DeckPreset, limit int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.decks[p.Name]; ok {
+		return fmt.Errorf("%w: %s", ErrDeckExists, p.Name)
+	}
+	if len(m.decks) >= limit {
+		return ErrDeckLimit
+	}
+	m.decks[p.Name] = p
+	return nil
+}
+
+func (m *MemoryRepository) LoadDeck(name string) (*dtos.DeckPreset, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, ok := m.decks[name]
+	if !ok {
+		return nil, ErrDeckNotFound
+	}
+	return &p, nil
+}
+
+func (m *MemoryRepository) LoadDecks() ([]dtos.DeckPreset, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	decks := make([]dtos.DeckPreset, 0, len(m.decks))
+	for _, p := range m.decks {
+		decks = append(decks, p)
+	}
+	return decks, nil
+}
+
+func (m *MemoryRepository) Close() error {
+	return nil
+}
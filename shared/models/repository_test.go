@@ -0,0 +1,31 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"omgtant/claustroboard/shared/dtos"
+	"testing"
+)
+
+func TestMemoryRepositoryKeepsLastReplays(t *testing.T) {
+	m := NewMemoryRepository()
+	code := func(i int) GameCode { return GameCode(fmt.Sprintf("G%d", i)) }
+	for i := range maxMemoryReplays {
+		_ = m.SaveReplay(code(i), dtos.Replay{Code: code(i).String()})
+	}
+	// A rematch makes the first board's replay the latest
+	_ = m.SaveReplay(code(0), dtos.Replay{Code: code(0).String()})
+	_ = m.SaveReplay(code(maxMemoryReplays), dtos.Replay{Code: code(maxMemoryReplays).String()})
+
+	if len(m.replays) != maxMemoryReplays || len(m.replayOrder) != maxMemoryReplays {
+		t.Errorf("%d replays in %d slots, want %d", len(m.replays), len(m.replayOrder), maxMemoryReplays)
+	}
+	if _, err := m.LoadReplay(code(1)); !errors.Is(err, ErrReplayNotFound) {
+		t.Errorf("oldest replay: got %v, want ErrReplayNotFound", err)
+	}
+	for _, c := range []GameCode{code(0), code(2), code(maxMemoryReplays)} {
+		if _, err := m.LoadReplay(c); err != nil {
+			t.Errorf("replay %s: %v", c, err)
+		}
+	}
+}
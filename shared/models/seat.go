@@ -0,0 +1,40 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// IssueToken creates the reconnect token of a seated player,
+// replacing any previous one.
+func IssueToken(code GameCode, nickname string) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(buf)
+
+	err := withBoard(code, func(b *Board) error {
+		if b.tokens == nil {
+			b.tokens = make(map[string]string)
+		}
+		b.tokens[nickname] = token
+		b.persist()
+		return nil
+	})
+	return token, err
+}
+
+// SeatForToken returns the nickname of the player holding the reconnect token.
+func SeatForToken(code GameCode, token string) (nickname string, err error) {
+	err = withBoard(code, func(b *Board) error {
+		for p, t := range b.tokens {
+			if token != "" && t == token {
+				nickname = p
+				return nil
+			}
+		}
+		return ErrInvalidToken
+	})
+	return nickname, err
+}
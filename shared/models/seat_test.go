@@ -0,0 +1,58 @@
+package models
+
+import (
+	"errors"
+	"omgtant/claustroboard/shared/dtos"
+	"testing"
+)
+
+func TestSeatForToken(t *testing.T) {
+	UseRepository(NewMemoryRepository())
+	code, err := NewGameBoard([]string{"a", "b"}, dtos.GameConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokenA, err := IssueToken(code, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokenB, err := IssueToken(code, "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p, err := SeatForToken(code, tokenA); err != nil || p != "a" {
+		t.Errorf("token of a: got %q, %v", p, err)
+	}
+	for _, token := range []string{"", "wrong", tokenA[:len(tokenA)-1]} {
+		if _, err := SeatForToken(code, token); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("token %q: got %v, want ErrInvalidToken", token, err)
+		}
+	}
+
+	// A new token replaces the previous one
+	renewed, err := IssueToken(code, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SeatForToken(code, tokenA); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("replaced token: got %v, want ErrInvalidToken", err)
+	}
+	if p, err := SeatForToken(code, renewed); err != nil || p != "a" {
+		t.Errorf("renewed token of a: got %q, %v", p, err)
+	}
+
+	// Seats are reclaimed during the game, until the grace period is over
+	if _, _, err := StartGame(code, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if p, err := SeatForToken(code, tokenB); err != nil || p != "b" {
+		t.Errorf("token of b in game: got %q, %v", p, err)
+	}
+	if _, err := Leave(code, "b"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SeatForToken(code, tokenB); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("token of a departed player: got %v, want ErrInvalidToken", err)
+	}
+}
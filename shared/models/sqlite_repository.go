@@ -0,0 +1,333 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"omgtant/claustroboard/shared/dtos"
+	"strings"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS boards (
+	code  TEXT PRIMARY KEY,
+	phase TEXT NOT NULL,
+	state TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS players (
+	code     TEXT NOT NULL REFERENCES boards(code) ON DELETE CASCADE,
+	seat     INTEGER NOT NULL,
+	nickname TEXT NOT NULL,
+	token    TEXT NOT NULL,
+	has_left INTEGER NOT NULL,
+	PRIMARY KEY (code, seat)
+);
+CREATE TABLE IF NOT EXISTS moves (
+	code  TEXT NOT NULL REFERENCES boards(code) ON DELETE CASCADE,
+	turn  INTEGER NOT NULL,
+	delta TEXT NOT NULL,
+	PRIMARY KEY (code, turn)
+);
+CREATE TABLE IF NOT EXISTS replays (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	code        TEXT NOT NULL,
+	finished_at TEXT NOT NULL,
+	replay      TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS replays_code ON replays (code);
+CREATE TABLE IF NOT EXISTS decks (
+	name   TEXT PRIMARY KEY,
+	preset TEXT NOT NULL
+);
+`
+
+// SQLiteRepository stores boards in an SQLite database file.
+type SQLiteRepository struct {
+	db *sql.DB
+}
+
+var _ BoardRepository = &SQLiteRepository{}
+
+// NewSQLiteRepository opens the database at dsn (e.g. "./db.sqlite?_time_format=sqlite")
+// and creates the tables it is missing.
+func NewSQLiteRepository(dsn string) (*SQLiteRepository, error) {
+	// Pragmas in the DSN apply to every pooled connection, not just the first one
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+	db, err := sql.Open("sqlite", dsn+sep+"_pragma=foreign_keys(1)")
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLiteRepository{db: db}, nil
+}
+
+func (s *SQLiteRepository) SaveBoard(r BoardRecord) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		`INSERT INTO boards (code, phase, state) VALUES (?, ?, ?)
+		ON CONFLICT (code) DO UPDATE SET phase = excluded.phase, state = excluded.state`,
+		r.Code, r.Phase, string(r.State),
+	); err != nil {
+		return err
+	}
+
+	// Seats only change in the lobby, rows that did not change are left untouched
+	for i, seat := range r.Seats {
+		if _, err := tx.Exec(
+			`INSERT INTO players (code, seat, nickname, token, has_left) VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT (code, seat) DO UPDATE SET
+				nickname = excluded.nickname, token = excluded.token, has_left = excluded.has_left
+			WHERE (nickname, token, has_left) IS NOT (excluded.nickname, excluded.token, excluded.has_left)`,
+			r.Code, i, seat.Nickname, seat.Token, seat.Left,
+		); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec("DELETE FROM players WHERE code = ? AND seat >= ?", r.Code, len(r.Seats)); err != nil {
+		return err
+	}
+
+	stored, err := storedMoves(tx, r)
+	if err != nil {
+		return err
+	}
+	if stored == 0 {
+		// The log starts over on a rematch
+		if _, err := tx.Exec("DELETE FROM moves WHERE code = ?", r.Code); err != nil {
+			return err
+		}
+	}
+	for _, d := range r.Log[stored:] {
+		buf, err := json.Marshal(d)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(
+			"INSERT INTO moves (code, turn, delta) VALUES (?, ?, ?)",
+			r.Code, d.Turn, string(buf),
+		); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// storedMoves returns how many deltas of the log of r are already stored.
+// The log only grows during a game, so the stored moves are a prefix of it
+// unless their last one is not in the log, e.g. after a rematch.
+func storedMoves(tx *sql.Tx, r BoardRecord) (int, error) {
+	var buf string
+	err := tx.QueryRow("SELECT delta FROM moves WHERE code = ? ORDER BY turn DESC LIMIT 1", r.Code).Scan(&buf)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	var last dtos.Delta
+	if err := json.Unmarshal([]byte(buf), &last); err != nil {
+		return 0, err
+	}
+	for i, d := range r.Log {
+		if d.Turn == last.Turn && d.Hash == last.Hash {
+			return i + 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func (s *SQLiteRepository) DeleteBoard(code GameCode) error {
+	_, err := s.db.Exec("DELETE FROM boards WHERE code = ?", code)
+	return err
+}
+
+func (s *SQLiteRepository) LoadBoards() ([]BoardRecord, error) {
+	records := []BoardRecord{}
+	index := make(map[GameCode]int)
+
+	rows, err := s.db.Query("SELECT code, phase, state FROM boards")
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var r BoardRecord
+		var state string
+		if err := rows.Scan(&r.Code, &r.Phase, &state); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		r.State = json.RawMessage(state)
+		index[r.Code] = len(records)
+		records = append(records, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = s.db.Query("SELECT code, nickname, token, has_left FROM players ORDER BY code, seat")
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var code GameCode
+		var seat SeatRecord
+		if err := rows.Scan(&code, &seat.Nickname, &seat.Token, &seat.Left); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if i, ok := index[code]; ok {
+			records[i].Seats = append(records[i].Seats, seat)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = s.db.Query("SELECT code, delta FROM moves ORDER BY code, turn")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var code GameCode
+		var buf string
+		if err := rows.Scan(&code, &buf); err != nil {
+			return nil, err
+		}
+		var d dtos.Delta
+		if err := json.Unmarshal([]byte(buf), &d); err != nil {
+			return nil, err
+		}
+		if i, ok := index[code]; ok {
+			records[i].Log = append(records[i].Log, d)
+		}
+	}
+	return records, rows.Err()
+}
+
+func (s *SQLiteRepository) SaveReplay(code GameCode, r dtos.Replay) error {
+	buf, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(
+		"INSERT INTO replays (code, finished_at, replay) VALUES (?, ?, ?)",
+		code, r.FinishedAt.UTC().Format(time.RFC3339Nano), string(buf),
+	)
+	return err
+}
+
+func (s *SQLiteRepository) LoadReplay(code GameCode) (*dtos.Replay, error) {
+	var buf string
+	err := s.db.QueryRow("SELECT replay FROM replays WHERE code = ? ORDER BY id DESC LIMIT 1", code).Scan(&buf)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrReplayNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var r dtos.Replay
+	if err := json.Unmarshal([]byte(buf), &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (s *SQLiteRepository) SaveDeck(p dtos.DeckPreset, limit int) error {
+	buf, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	// Counting and inserting in one statement keeps concurrent saves under the limit
+	res, err := s.db.Exec(
+		"INSERT INTO decks (name, preset) SELECT ?, ? WHERE (SELECT COUNT(*) FROM decks) < ?",
+		p.Name, string(buf), limit,
+	)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("%w: %s", ErrDeckExists, p.Name)
+	}
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrDeckLimit
+	}
+	return nil
+}
+
+func isUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	code := sqliteErr.Code()
+	return code == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY || code == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+}
+
+func (s *SQLiteRepository) LoadDeck(name string) (*dtos.DeckPreset, error) {
+	var buf string
+	err := s.db.QueryRow("SELECT preset FROM decks WHERE name = ?", name).Scan(&buf)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDeckNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var p dtos.DeckPreset
+	if err := json.Unmarshal([]byte(buf), &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (s *SQLiteRepository) LoadDecks() ([]dtos.DeckPreset, error) {
+	rows, err := s.db.Query("SELECT preset FROM decks")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	decks := []dtos.DeckPreset{}
+	for rows.Next() {
+		var buf string
+		if err := rows.Scan(&buf); err != nil {
+			return nil, err
+		}
+		var p dtos.DeckPreset
+		if err := json.Unmarshal([]byte(buf), &p); err != nil {
+			return nil, err
+		}
+		decks = append(decks, p)
+	}
+	return decks, rows.Err()
+}
+
+func (s *SQLiteRepository) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"encoding/json"
+	"omgtant/claustroboard/shared/dtos"
+	"path/filepath"
+	"testing"
+)
+
+func TestSQLiteSaveBoardAppendsMoves(t *testing.T) {
+	r, err := NewSQLiteRepository(filepath.Join(t.TempDir(), "db.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	log := []dtos.Delta{{Turn: 1, Hash: "a"}, {Turn: 2, Hash: "b"}, {Turn: 3, Hash: "c"}}
+	rec := BoardRecord{
+		Code:  "ABCD",
+		Phase: PhaseStarted,
+		Seats: []SeatRecord{{Nickname: "a"}, {Nickname: "b"}, {Nickname: "c"}},
+		State: json.RawMessage(`{}`),
+	}
+	for n := range log {
+		rec.Log = log[:n+1]
+		if err := r.SaveBoard(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkStored(t, r, 3, "c", 3)
+
+	// A rematch without c starts the log over
+	rec.Seats = rec.Seats[:2]
+	rec.Log = []dtos.Delta{{Turn: 1, Hash: "x"}}
+	if err := r.SaveBoard(rec); err != nil {
+		t.Fatal(err)
+	}
+	checkStored(t, r, 2, "x", 1)
+}
+
+func checkStored(t *testing.T, r *SQLiteRepository, seats int, lastHash string, moves int) {
+	t.Helper()
+	records, err := r.LoadBoards()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("%d boards stored", len(records))
+	}
+	rec := records[0]
+	if len(rec.Seats) != seats || len(rec.Log) != moves || rec.Log[moves-1].Hash != lastHash {
+		t.Errorf("stored %d seats and log %+v, want %d seats and %d moves ending with %s",
+			len(rec.Seats), rec.Log, seats, moves, lastHash)
+	}
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+)
+
+var errTurnOver = errors.New("turn is already over")
+
+// TurnClock is the time the current player has left, sent to clients as is.
+type TurnClock struct {
+	Turn     uint32    `json:"turn"`
+	Player   string    `json:"player"`
+	Deadline time.Time `json:"deadline"`
+}
+
+// CurrentTurnClock returns the clock of the turn being played, or nil when turns are not timed.
+func CurrentTurnClock(code GameCode) (clock *TurnClock, err error) {
+	err = withBoard(code, func(b *Board) error {
+		player, err := b.currentPlayer()
+		if err != nil || b.TurnDeadline.IsZero() {
+			return nil
+		}
+		clock = &TurnClock{Turn: b.Game.CheckTurn, Player: player, Deadline: b.TurnDeadline}
+		return nil
+	})
+	return clock, err
+}
+
+// TimeoutTurn ends the given turn once the current player ran out of time,
+// as the board's timeout policy says. It fails if the turn ended meanwhile.
+func TimeoutTurn(code GameCode, turn uint32) (out TurnOutcome, err error) {
+	err = withBoard(code, func(b *Board) error {
+		out, err = b.timeoutTurn(turn)
+		return err
+	})
+	return out, err
+}
+
+func (b *Board) timeoutTurn(turn uint32) (TurnOutcome, error) {
+	if b.Phase != PhaseStarted || b.Game.CheckTurn != turn {
+		return TurnOutcome{}, errTurnOver
+	}
+	if b.TurnDeadline.IsZero() || time.Now().Before(b.TurnDeadline) {
+		return TurnOutcome{}, errors.New("turn time is not up")
+	}
+
+	// Seeded by the game so that replaying it gives the same random moves
+	rng := rand.New(rand.NewSource(b.Game.Seed + int64(turn)))
+	delta, err := b.Game.Timeout(b.TimeoutPolicy, rng)
+	if err != nil {
+		return TurnOutcome{}, err
+	}
+
+	b.endTurn(delta)
+	return TurnOutcome{Delta: delta, Result: b.Result}, nil
+}
+
+// resetTurnDeadline gives the current player a full turn, if turns are timed.
+func (b *Board) resetTurnDeadline() {
+	if b.TurnTimeLimit <= 0 || b.Phase != PhaseStarted {
+		b.TurnDeadline = time.Time{}
+		return
+	}
+	b.TurnDeadline = time.Now().Add(b.TurnTimeLimit)
+}
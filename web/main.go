@@ -35,6 +35,18 @@ func GetRouter(projectRoot string) *http.ServeMux {
 	// websocket routes
 	apiMux.HandleFunc("GET /start-game", routers.StartGameWS)
 	apiMux.HandleFunc("GET /join/{id}", routers.JoinGameWS)
+	apiMux.HandleFunc("GET /rejoin/{id}", routers.RejoinGameWS)
+	apiMux.HandleFunc("GET /watch/{id}", routers.WatchGameWS)
+	// lobby browser
+	apiMux.HandleFunc("GET /lobbies", routers.ListLobbies)
+	apiMux.HandleFunc("GET /lobbies/stream", routers.StreamLobbies)
+	// deck library
+	apiMux.HandleFunc("GET /decks", routers.ListDecks)
+	apiMux.HandleFunc("POST /decks", routers.SaveDeck)
+	// match history
+	apiMux.HandleFunc("GET /games/{code}/replay", routers.GetReplay)
+	// monitoring
+	apiMux.HandleFunc("GET /stats", routers.Stats)
 	// debug routes
 	if config.Get().ENVIRONMENT == "development" {
 		apiMux.HandleFunc("GET /get-pid", getPID)
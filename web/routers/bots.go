@@ -0,0 +1,107 @@
+package routers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"omgtant/claustroboard/shared/engine"
+	"omgtant/claustroboard/shared/models"
+)
+
+// Pause before each bot move, so that players can follow the game.
+const botMoveDelay = 700 * time.Millisecond
+
+func handleAddBot(c *wsClient, data json.RawMessage) {
+	req := struct {
+		Difficulty engine.BotLevel `json:"difficulty"`
+	}{engine.BotGreedy}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &req); err != nil {
+			c.writeError(err)
+			return
+		}
+	}
+
+	if _, err := models.AddBot(c.gameCode, c.nickname, req.Difficulty); err != nil {
+		c.writeError(err)
+		return
+	}
+	broadcastPlayerList(c.gameCode)
+}
+
+// Games whose bots are playing, mapped to whether the turn changed meanwhile
+// and their loop should check again before it stops. Guarded by mu.
+var botLoops = make(map[models.GameCode]bool)
+
+// runBots plays the turns of bots until a human is to move or the game ends.
+// It is started by onTurnChanged, and only runs once per game at a time.
+func runBots(gameCode models.GameCode) {
+	mu.Lock()
+	if _, running := botLoops[gameCode]; running {
+		botLoops[gameCode] = true
+		mu.Unlock()
+		return
+	}
+	botLoops[gameCode] = false
+	mu.Unlock()
+
+	for {
+		time.Sleep(botMoveDelay)
+
+		mu.Lock()
+		botLoops[gameCode] = false
+		mu.Unlock()
+
+		out, err := models.PlayBot(gameCode)
+		if errors.Is(err, models.ErrGameNotFound) || errors.Is(err, models.ErrGameErrored) {
+			endBots(gameCode)
+			return
+		}
+		if err != nil {
+			fmt.Printf("Bot failed to move in game %s: %v\n", gameCode, err)
+			if stopBots(gameCode) {
+				return
+			}
+			continue
+		}
+		if out == nil {
+			// Not a bot's turn, unless it changed while the bot was thinking
+			if stopBots(gameCode) {
+				return
+			}
+			continue
+		}
+		broadcastEvent(gameCode, event{
+			Type: "they-moved",
+			Data: out.Delta,
+		})
+		if out.Result != nil {
+			broadcastGameOver(gameCode, out.Result)
+			endBots(gameCode)
+			return
+		}
+		scheduleTurnTimer(gameCode)
+	}
+}
+
+// stopBots ends the bot loop of a game, unless the turn changed since its last
+// move. It reports whether the loop stopped.
+func stopBots(gameCode models.GameCode) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	if botLoops[gameCode] {
+		botLoops[gameCode] = false
+		return false
+	}
+	delete(botLoops, gameCode)
+	return true
+}
+
+// endBots ends the bot loop of a game that is over.
+func endBots(gameCode models.GameCode) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(botLoops, gameCode)
+}
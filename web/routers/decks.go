@@ -0,0 +1,47 @@
+package routers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"omgtant/claustroboard/shared/dtos"
+	"omgtant/claustroboard/shared/models"
+)
+
+// Largest deck a player may save, in bytes of JSON.
+const maxDeckSize = 32 << 10
+
+// ListDecks returns the builtin and saved deck presets.
+func ListDecks(w http.ResponseWriter, r *http.Request) {
+	decks, err := models.ListDecks()
+	if err != nil {
+		httpError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(decks)
+}
+
+// SaveDeck stores a preset sent as `{"name": ..., "description": ..., "config": config}`.
+func SaveDeck(w http.ResponseWriter, r *http.Request) {
+	var preset dtos.DeckPreset
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxDeckSize)).Decode(&preset); err != nil {
+		var sizeErr *http.MaxBytesError
+		if !errors.As(err, &sizeErr) {
+			err = fmt.Errorf("%w: %v", models.ErrInvalidConfig, err)
+		}
+		httpError(w, err)
+		return
+	}
+
+	saved, err := models.SaveDeck(preset)
+	if err != nil {
+		httpError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(saved)
+}
@@ -0,0 +1,88 @@
+package routers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"omgtant/claustroboard/shared/models"
+)
+
+// errorKind is how an error is reported to clients: the HTTP status of requests
+// failing with it, and the code of `error` events, which clients can rely on.
+type errorKind struct {
+	err    error
+	status int
+	code   string
+}
+
+var errorKinds = []errorKind{
+	{models.ErrGameNotFound, http.StatusNotFound, "game-not-found"},
+	{models.ErrGameFull, http.StatusConflict, "game-full"},
+	{models.ErrAlreadyStarted, http.StatusGone, "already-started"},
+	{models.ErrNotStarted, http.StatusConflict, "not-started"},
+	{models.ErrNicknameTaken, http.StatusConflict, "nickname-taken"},
+	{models.ErrInvalidToken, http.StatusForbidden, "invalid-token"},
+	{models.ErrInvalidConfig, http.StatusBadRequest, "invalid-config"},
+	{models.ErrNotHost, http.StatusForbidden, "not-host"},
+	{models.ErrNoSuchPlayer, http.StatusNotFound, "no-such-player"},
+	{models.ErrNotAllowed, http.StatusForbidden, "not-allowed"},
+	{models.ErrNotYourTurn, http.StatusConflict, "not-your-turn"},
+	{models.ErrInvalidMove, http.StatusUnprocessableEntity, "invalid-move"},
+	{models.ErrOutOfSync, http.StatusConflict, "out-of-sync"},
+	{models.ErrReplayNotFound, http.StatusNotFound, "replay-not-found"},
+	{models.ErrDeckNotFound, http.StatusNotFound, "deck-not-found"},
+	{models.ErrDeckExists, http.StatusConflict, "deck-exists"},
+	{models.ErrDeckLimit, http.StatusInsufficientStorage, "deck-limit"},
+	{models.ErrGameErrored, http.StatusInternalServerError, "game-errored"},
+	{errShuttingDown, http.StatusServiceUnavailable, "shutting-down"},
+}
+
+var (
+	badRequest = errorKind{status: http.StatusBadRequest, code: "bad-request"}
+	internal   = errorKind{status: http.StatusInternalServerError, code: "internal"}
+	tooLarge   = errorKind{status: http.StatusRequestEntityTooLarge, code: "too-large"}
+)
+
+func kindOf(err error) errorKind {
+	for _, k := range errorKinds {
+		if errors.Is(err, k.err) {
+			return k
+		}
+	}
+	var sizeErr *http.MaxBytesError
+	if errors.As(err, &sizeErr) {
+		return tooLarge
+	}
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+		return badRequest
+	}
+	return internal
+}
+
+// httpError replies to a failed request with the status matching err
+// and the same payload as `error` events.
+func httpError(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(kindOf(err).status)
+	_ = json.NewEncoder(w).Encode(newErrorPayload(err))
+}
+
+// errorPayload is the body of failed requests and the payload of `error` events.
+type errorPayload struct {
+	Code     string                 `json:"code"`
+	Message  string                 `json:"message"`
+	Problems []models.ConfigProblem `json:"problems,omitempty"` // Everything wrong with a rejected config
+}
+
+func newErrorPayload(err error) errorPayload {
+	payload := errorPayload{Code: kindOf(err).code, Message: err.Error()}
+	var configErr *models.ConfigError
+	if errors.As(err, &configErr) {
+		payload.Problems = configErr.Problems
+	}
+	return payload
+}
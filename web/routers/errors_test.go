@@ -0,0 +1,91 @@
+package routers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"strings"
+	"testing"
+
+	"omgtant/claustroboard/shared/models"
+)
+
+func TestKindOf(t *testing.T) {
+	var syntaxErr error = &json.SyntaxError{}
+	var typeErr error = &json.UnmarshalTypeError{Value: "string"}
+
+	cases := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{"ErrGameNotFound", models.ErrGameNotFound, http.StatusNotFound, "game-not-found"},
+		{"ErrGameFull", models.ErrGameFull, http.StatusConflict, "game-full"},
+		{"ErrAlreadyStarted", models.ErrAlreadyStarted, http.StatusGone, "already-started"},
+		{"ErrNotStarted", models.ErrNotStarted, http.StatusConflict, "not-started"},
+		{"ErrNicknameTaken", models.ErrNicknameTaken, http.StatusConflict, "nickname-taken"},
+		{"ErrInvalidToken", models.ErrInvalidToken, http.StatusForbidden, "invalid-token"},
+		{"ErrInvalidConfig", models.ErrInvalidConfig, http.StatusBadRequest, "invalid-config"},
+		{"ErrNotHost", models.ErrNotHost, http.StatusForbidden, "not-host"},
+		{"ErrNoSuchPlayer", models.ErrNoSuchPlayer, http.StatusNotFound, "no-such-player"},
+		{"ErrNotAllowed", models.ErrNotAllowed, http.StatusForbidden, "not-allowed"},
+		{"ErrNotYourTurn", models.ErrNotYourTurn, http.StatusConflict, "not-your-turn"},
+		{"ErrInvalidMove", models.ErrInvalidMove, http.StatusUnprocessableEntity, "invalid-move"},
+		{"ErrOutOfSync", models.ErrOutOfSync, http.StatusConflict, "out-of-sync"},
+		{"ErrReplayNotFound", models.ErrReplayNotFound, http.StatusNotFound, "replay-not-found"},
+		{"ErrDeckNotFound", models.ErrDeckNotFound, http.StatusNotFound, "deck-not-found"},
+		{"ErrDeckExists", models.ErrDeckExists, http.StatusConflict, "deck-exists"},
+		{"ErrDeckLimit", models.ErrDeckLimit, http.StatusInsufficientStorage, "deck-limit"},
+		{"ErrGameErrored", models.ErrGameErrored, http.StatusInternalServerError, "game-errored"},
+		{"errShuttingDown", errShuttingDown, http.StatusServiceUnavailable, "shutting-down"},
+
+		{"wrapped", fmt.Errorf("%w: ABCD", models.ErrGameFull), http.StatusConflict, "game-full"},
+		{"config error", &models.ConfigError{}, http.StatusBadRequest, "invalid-config"},
+		{"body too large", fmt.Errorf("reading body: %w", &http.MaxBytesError{Limit: 1}), http.StatusRequestEntityTooLarge, "too-large"},
+		{"json syntax", syntaxErr, http.StatusBadRequest, "bad-request"},
+		{"json type", typeErr, http.StatusBadRequest, "bad-request"},
+		{"unknown", errors.New("disk on fire"), http.StatusInternalServerError, "internal"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if k := kindOf(tc.err); k.status != tc.status || k.code != tc.code {
+				t.Errorf("got %d %s, want %d %s", k.status, k.code, tc.status, tc.code)
+			}
+		})
+	}
+
+	// A new error in models must get its own case, and its own kind
+	tested := map[string]bool{}
+	for _, tc := range cases {
+		tested[tc.name] = true
+	}
+	for _, name := range modelErrors(t) {
+		if !tested[name] {
+			t.Errorf("models.%s is not tested", name)
+		}
+	}
+}
+
+// modelErrors lists the Err variables declared in the models package.
+func modelErrors(t *testing.T) []string {
+	t.Helper()
+	f, err := parser.ParseFile(token.NewFileSet(), "../../shared/models/errors.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, obj := range f.Scope.Objects {
+		if obj.Kind == ast.Var && strings.HasPrefix(obj.Name, "Err") {
+			names = append(names, obj.Name)
+		}
+	}
+	if len(names) == 0 {
+		t.Fatal("no errors found in models")
+	}
+	return names
+}
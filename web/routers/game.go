@@ -2,49 +2,77 @@ package routers
 
 import (
 	"encoding/json"
-	"errors"
 
 	"omgtant/claustroboard/shared/config"
 	"omgtant/claustroboard/shared/dtos"
 	"omgtant/claustroboard/shared/models"
+
+	"github.com/coder/websocket"
 )
 
 var (
 	inboundHandlers = map[string]func(*wsClient, json.RawMessage){
-		"start":      handleStartGame,
-		"broadcast":  handleBroadcast,
-		"my-move":    handleMove,
-		"come-again": handleBroadcast,
+		"start":         handleStartGame,
+		"broadcast":     handleBroadcast,
+		"my-move":       handleMove,
+		"come-again":    handleComeAgain,
+		"kick":          handleKick,
+		"transfer-host": handleTransferHost,
+		"add-bot":       handleAddBot,
+		"state-hash":    handleStateHash,
+	}
+	// Actions spectators may send, all others are rejected
+	spectatorActions = map[string]bool{
+		"come-again": true,
+		"state-hash": true,
 	}
 )
 
 func broadcastPlayerList(gameCode models.GameCode) {
-	board, err := models.GetBoard(gameCode)
+	players, bots, err := models.PlayerList(gameCode)
 	if err != nil {
 		return
 	}
 
-	players := make([]string, len(board.Players))
-	copy(players, board.Players)
-
 	broadcastEvent(gameCode, event{
 		Type: "playerlist-changed",
-		Data: players,
+		Data: map[string]any{
+			"players":    players,
+			"bots":       bots,
+			"spectators": countSpectators(gameCode),
+		},
+	})
+}
+
+func broadcastHost(gameCode models.GameCode) {
+	host, err := models.Host(gameCode)
+	if err != nil {
+		return
+	}
+
+	broadcastEvent(gameCode, event{
+		Type: "host-changed",
+		Data: map[string]string{"host": host},
 	})
 }
 
 func handleStartGame(c *wsClient, _ json.RawMessage) {
-	_, err := models.StartGame(c.gameCode)
+	snap, result, err := models.StartGame(c.gameCode, c.nickname)
 	if err != nil {
 		c.writeError(err)
 		return
 	}
 
-	snap, _ := models.Snapshot(c.gameCode)
 	broadcastEvent(c.gameCode, event{
 		Type: "started",
 		Data: snap,
 	})
+	// Everyone but one player may be stuck from the start
+	if result != nil {
+		broadcastGameOver(c.gameCode, result)
+		return
+	}
+	onTurnChanged(c.gameCode)
 }
 
 func handleBroadcast(c *wsClient, data json.RawMessage) {
@@ -57,43 +85,123 @@ func handleBroadcast(c *wsClient, data json.RawMessage) {
 	})
 }
 
-func handleMove(c *wsClient, data json.RawMessage) {
-	board, err := models.GetBoard(c.gameCode)
+type playerTarget struct {
+	Nickname string `json:"nickname"`
+}
+
+func handleKick(c *wsClient, data json.RawMessage) {
+	var target playerTarget
+	if err := json.Unmarshal(data, &target); err != nil {
+		c.writeError(err)
+		return
+	}
+
+	departure, err := models.Kick(c.gameCode, c.nickname, target.Nickname)
 	if err != nil {
 		c.writeError(err)
 		return
 	}
 
-	board.Lock()
-	defer board.Unlock()
+	mu.Lock()
+	s := seat{c.gameCode, target.Nickname}
+	if t, ok := pendingLeaves[s]; ok {
+		t.Stop()
+		delete(pendingLeaves, s)
+	}
+	for cl := range gameClients[c.gameCode] {
+		if cl.nickname == target.Nickname && !cl.spectator {
+			delete(gameClients[c.gameCode], cl)
+			cl.write("kicked", nil)
+			cl.closeAfterFlush(websocket.StatusPolicyViolation, "kicked")
+		}
+	}
+	mu.Unlock()
+
+	broadcastPlayerList(c.gameCode)
+	broadcastForfeit(c.gameCode, departure)
+}
+
+func handleTransferHost(c *wsClient, data json.RawMessage) {
+	var target playerTarget
+	if err := json.Unmarshal(data, &target); err != nil {
+		c.writeError(err)
+		return
+	}
+
+	if err := models.TransferHost(c.gameCode, c.nickname, target.Nickname); err != nil {
+		c.writeError(err)
+		return
+	}
+	broadcastHost(c.gameCode)
+}
 
-	_, idx, err := board.GetCurrent()
+// Replies with the deltas applied after `since`, or a full snapshot without it.
+func handleComeAgain(c *wsClient, data json.RawMessage) {
+	var req struct {
+		Since *uint32 `json:"since"`
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &req); err != nil {
+			c.writeError(err)
+			return
+		}
+	}
+
+	resync, err := models.Resync(c.gameCode, req.Since)
 	if err != nil {
 		c.writeError(err)
 		return
 	}
+	c.write("come-again", resync)
+}
 
-	currentPlayer := board.Players[idx]
-	if currentPlayer != c.nickname {
-		c.writeError(errors.New("it is not your turn"))
+// Checks the state hash of the client, and resyncs it with a snapshot when it differs.
+func handleStateHash(c *wsClient, data json.RawMessage) {
+	var req struct {
+		Turn uint32 `json:"turn"`
+		Hash string `json:"hash"`
+	}
+	if err := json.Unmarshal(data, &req); err != nil {
+		c.writeError(err)
 		return
 	}
 
-	println("turn", currentPlayer)
+	resync, err := models.CheckStateHash(c.gameCode, req.Turn, req.Hash)
+	if err != nil {
+		c.writeError(err)
+		return
+	}
+	if resync != nil {
+		c.write("come-again", resync)
+	}
+}
 
+func handleMove(c *wsClient, data json.RawMessage) {
 	var inputDelta dtos.Delta
 	if err := json.Unmarshal(data, &inputDelta); err != nil {
 		c.writeError(err)
 		return
 	}
-	delta, err := board.Move(inputDelta.Move)
 
+	out, err := models.Move(c.gameCode, c.nickname, inputDelta.Sequence())
 	if err != nil {
 		c.writeError(err)
 		return
 	}
 	broadcastEvent(c.gameCode, event{
 		Type: "they-moved",
-		Data: delta,
+		Data: out.Delta,
+	})
+	if out.Result != nil {
+		broadcastGameOver(c.gameCode, out.Result)
+		return
+	}
+	onTurnChanged(c.gameCode)
+}
+
+func broadcastGameOver(gameCode models.GameCode, result *dtos.GameResult) {
+	broadcastEvent(gameCode, event{
+		Type: "game-over",
+		Data: result,
 	})
 }
@@ -1,13 +1,11 @@
 package routers
 
 import (
-	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"omgtant/claustroboard/shared/dtos"
 	"omgtant/claustroboard/shared/models"
-
-	"github.com/coder/websocket/wsjson"
 )
 
 func StartGameWS(w http.ResponseWriter, r *http.Request) {
@@ -17,28 +15,45 @@ func StartGameWS(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// A preset's config, or an empty one, with the fields of `config` replaced
 	gameConfig := dtos.GameConfig{}
+	if preset := r.URL.Query().Get("preset"); preset != "" {
+		var err error
+		if gameConfig, err = models.PresetConfig(preset); err != nil {
+			httpError(w, err)
+			return
+		}
+	}
 	if r.URL.Query().Has("config") {
 		gameConfigJSON := r.URL.Query().Get("config")
 		if err := json.Unmarshal([]byte(gameConfigJSON), &gameConfig); err != nil {
-			http.Error(w, "invalid config", http.StatusBadRequest)
+			httpError(w, fmt.Errorf("%w: %v", models.ErrInvalidConfig, err))
 			return
 		}
 	}
 
 	code, err := models.NewGameBoard([]string{nickname}, gameConfig)
 	if err != nil {
-		http.Error(w, "create failed", http.StatusInternalServerError)
+		httpError(w, err)
+		return
+	}
+	token, err := models.IssueToken(code, nickname)
+	if err != nil {
+		_, _ = models.Leave(code, nickname)
+		httpError(w, err)
 		return
 	}
 
-	client, err := upgradeAndRegister(w, r, code, nickname)
+	client, err := upgradeAndRegister(w, r, code, nickname, false)
 	if err != nil {
-		http.Error(w, "upgrade failed", http.StatusInternalServerError)
+		httpError(w, err)
 		return
 	}
 
-	client.write("created", map[string]string{"code": code.String()})
+	client.write("created", map[string]string{
+		"code":  code.String(),
+		"token": token,
+	})
 	broadcastPlayerList(code)
 }
 
@@ -51,45 +66,110 @@ func JoinGameWS(w http.ResponseWriter, r *http.Request) {
 
 	code := models.GameCode(r.PathValue("id"))
 
-	board, err := models.GetBoard(code)
+	if err := models.Join(code, nickname); err != nil {
+		httpError(w, err)
+		return
+	}
+	// Without a token the player could not come back after a disconnection
+	token, err := models.IssueToken(code, nickname)
 	if err != nil {
-		http.Error(w, "not found", http.StatusNotFound)
+		_, _ = models.Leave(code, nickname)
+		httpError(w, err)
 		return
 	}
 
-	already := false
-	for _, p := range board.Players {
-		if p == nickname {
-			already = true
-			break
-		}
+	client, err := upgradeAndRegister(w, r, code, nickname, false)
+	if err != nil {
+		_, _ = models.Leave(code, nickname)
+		httpError(w, err)
+		return
 	}
-	if already {
-		http.Error(w, "a player with the same nickname already joined", http.StatusConflict)
+
+	host, _ := models.Host(code)
+	client.write("joined", map[string]string{
+		"code":  code.String(),
+		"you":   nickname,
+		"token": token,
+		"host":  host,
+	})
+
+	broadcastPlayerList(code)
+}
+
+// RejoinGameWS reattaches a player to their seat using the token
+// they received in `created` or `joined`.
+func RejoinGameWS(w http.ResponseWriter, r *http.Request) {
+	code := models.GameCode(r.PathValue("id"))
+	nickname, err := models.SeatForToken(code, r.URL.Query().Get("token"))
+	if err != nil {
+		httpError(w, err)
 		return
-	} else {
-		if err := models.Join(code, nickname); err != nil {
-			http.Error(w, err.Error(), http.StatusGone)
-			return
-		}
 	}
 
-	client, err := upgradeAndRegister(w, r, code, nickname)
+	mu.Lock()
+	reclaimSeat(seat{code, nickname})
+	mu.Unlock()
+
+	client, err := upgradeAndRegister(w, r, code, nickname, false)
 	if err != nil {
-		if !already {
-			_ = models.Leave(code, nickname)
-		}
-		http.Error(w, "upgrade failed", http.StatusInternalServerError)
+		mu.Lock()
+		holdSeat(seat{code, nickname})
+		mu.Unlock()
+		httpError(w, err)
 		return
 	}
 
-	_ = wsjson.Write(context.Background(), client.conn, event{
-		Type: "joined",
-		Data: map[string]string{
-			"code": code.String(),
-			"you":  nickname,
-		},
-	})
+	rejoined := struct {
+		Code     string      `json:"code"`
+		You      string      `json:"you"`
+		Host     string      `json:"host"`
+		Snapshot *dtos.Board `json:"snapshot,omitempty"`
+	}{
+		Code: code.String(),
+		You:  nickname,
+	}
+	rejoined.Host, _ = models.Host(code)
+	if resync, err := models.Resync(code, nil); err == nil {
+		rejoined.Snapshot = resync.Snapshot
+	}
+	client.write("rejoined", rejoined)
+
+	broadcastPlayerList(code)
+}
+
+// WatchGameWS connects a spectator, who receives every broadcast of the game
+// but cannot act in it.
+func WatchGameWS(w http.ResponseWriter, r *http.Request) {
+	code := models.GameCode(r.PathValue("id"))
+	host, err := models.Host(code)
+	if err != nil {
+		httpError(w, err)
+		return
+	}
+
+	client, err := upgradeAndRegister(w, r, code, "", true)
+	if err != nil {
+		httpError(w, err)
+		return
+	}
+
+	snap, deltas, err := models.History(code)
+	if err != nil {
+		client.writeError(err)
+		return
+	}
+	watching := struct {
+		Code     string       `json:"code"`
+		Host     string       `json:"host"`
+		Snapshot *dtos.Board  `json:"snapshot"`
+		Deltas   []dtos.Delta `json:"deltas"` // Lead from Snapshot to the current state
+	}{
+		Code:     code.String(),
+		Host:     host,
+		Snapshot: snap,
+		Deltas:   deltas,
+	}
+	client.write("watching", watching)
 
 	broadcastPlayerList(code)
 }
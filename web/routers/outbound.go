@@ -0,0 +1,159 @@
+package routers
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"omgtant/claustroboard/shared/config"
+
+	"github.com/coder/websocket"
+)
+
+const (
+	// How many events may wait for a client before it counts as too slow.
+	sendQueueSize = 64
+	writeTimeout  = 5 * time.Second
+	pingInterval  = 30 * time.Second
+)
+
+// What to do with a client whose send queue is full.
+type slowClientPolicy string
+
+const (
+	// Drop its queued events and tell it to resync with `come-again`.
+	slowClientResync slowClientPolicy = "resync"
+	// Close its socket, it may rejoin with its token.
+	slowClientDisconnect slowClientPolicy = "disconnect"
+)
+
+func currentSlowClientPolicy() slowClientPolicy {
+	if slowClientPolicy(config.Get().SLOW_CLIENT_POLICY) == slowClientDisconnect {
+		return slowClientDisconnect
+	}
+	return slowClientResync
+}
+
+// outbound is an entry of a client's send queue.
+type outbound struct {
+	msg []byte
+	// When non-zero, the connection is closed once everything queued before is sent.
+	closeStatus websocket.StatusCode
+	closeReason string
+}
+
+func (c *wsClient) write(t string, data any) {
+	c.send(event{Type: t, Data: data})
+}
+
+func (c *wsClient) writeError(err error) {
+	c.send(event{
+		Type: "error",
+		Data: newErrorPayload(err),
+	})
+}
+
+// send numbers evt after the previous event of the client and queues it for the writer.
+// It never blocks: a client whose queue is full is dealt with by the slow client policy.
+func (c *wsClient) send(evt event) {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if c.closing {
+		return
+	}
+
+	c.seq++
+	evt.Seq = c.seq
+	msg, err := json.Marshal(evt)
+	if err != nil {
+		return
+	}
+	select {
+	case c.queue <- outbound{msg: msg}:
+		return
+	default:
+	}
+
+	switch currentSlowClientPolicy() {
+	case slowClientDisconnect:
+		c.closing = true
+		go c.conn.Close(websocket.StatusPolicyViolation, "too slow")
+	default:
+		dropped := 1 + c.drain()
+		c.seq++
+		msg, _ := json.Marshal(event{
+			Type: "lagged",
+			Seq:  c.seq,
+			Data: map[string]int{"dropped": dropped},
+		})
+		// Only send adds to the queue, which was just emptied
+		c.queue <- outbound{msg: msg}
+	}
+}
+
+// drain empties the send queue and returns how many events it held.
+// Must be called with sendMu held.
+func (c *wsClient) drain() (n int) {
+	for {
+		select {
+		case <-c.queue:
+			n++
+		default:
+			return n
+		}
+	}
+}
+
+// closeAfterFlush closes the connection once every event queued so far is sent.
+// Events sent afterwards are discarded.
+func (c *wsClient) closeAfterFlush(status websocket.StatusCode, reason string) {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if c.closing {
+		return
+	}
+	c.closing = true
+	select {
+	case c.queue <- outbound{closeStatus: status, closeReason: reason}:
+	default:
+		go c.conn.Close(status, reason)
+	}
+}
+
+// writeLoop is the only writer of the connection, sending queued events in order.
+func (c *wsClient) writeLoop() {
+	for {
+		select {
+		case out := <-c.queue:
+			if out.closeStatus != 0 {
+				c.conn.Close(out.closeStatus, out.closeReason)
+				return
+			}
+			ctx, cancel := context.WithTimeout(c.ctx, writeTimeout)
+			err := c.conn.Write(ctx, websocket.MessageText, out.msg)
+			cancel()
+			if err != nil {
+				// Unblocks readLoop, which cleans up
+				c.conn.CloseNow()
+				return
+			}
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *wsClient) writePing() {
+	t := time.NewTicker(pingInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			ctx, cancel := context.WithTimeout(c.ctx, 2*time.Minute)
+			_ = c.conn.Ping(ctx)
+			cancel()
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
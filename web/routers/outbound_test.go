@@ -0,0 +1,193 @@
+package routers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"omgtant/claustroboard/shared/config"
+
+	"github.com/coder/websocket"
+)
+
+// newTestClient connects a client to a websocket peer standing for the browser.
+// Its writer is not started, so that tests decide when the queue drains.
+func newTestClient(t *testing.T) (*wsClient, *websocket.Conn) {
+	t.Helper()
+	accepted := make(chan *websocket.Conn, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		accepted <- conn
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	peer, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &wsClient{
+		conn:  <-accepted,
+		queue: make(chan outbound, sendQueueSize),
+	}
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+	t.Cleanup(func() {
+		c.cancel()
+		c.conn.CloseNow()
+		peer.CloseNow()
+	})
+	return c, peer
+}
+
+type receivedEvent struct {
+	Type string          `json:"type"`
+	Seq  uint64          `json:"seq"`
+	Data json.RawMessage `json:"data"`
+}
+
+func readEvent(t *testing.T, peer *websocket.Conn) (receivedEvent, error) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, msg, err := peer.Read(ctx)
+	if err != nil {
+		return receivedEvent{}, err
+	}
+	var evt receivedEvent
+	if err := json.Unmarshal(msg, &evt); err != nil {
+		t.Fatal(err)
+	}
+	return evt, nil
+}
+
+func usePolicy(t *testing.T, policy slowClientPolicy) {
+	previous := config.Get().SLOW_CLIENT_POLICY
+	config.Get().SLOW_CLIENT_POLICY = string(policy)
+	t.Cleanup(func() { config.Get().SLOW_CLIENT_POLICY = previous })
+}
+
+func TestEventsInOrder(t *testing.T) {
+	c, peer := newTestClient(t)
+	go c.writeLoop()
+
+	// Concurrent senders, fewer events than the queue holds
+	const senders, each = 4, 10
+	var wg sync.WaitGroup
+	for s := range senders {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range each {
+				c.write("test", [2]int{s, i})
+			}
+		}()
+	}
+	wg.Wait()
+
+	next := make([]int, senders)
+	for want := uint64(1); want <= senders*each; want++ {
+		evt, err := readEvent(t, peer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if evt.Seq != want {
+			t.Fatalf("seq %d, want %d", evt.Seq, want)
+		}
+		var data [2]int
+		if err := json.Unmarshal(evt.Data, &data); err != nil {
+			t.Fatal(err)
+		}
+		if s, i := data[0], data[1]; i != next[s] {
+			t.Errorf("event %d of sender %d, want %d", i, s, next[s])
+		} else {
+			next[s]++
+		}
+	}
+}
+
+func TestFullQueueResyncs(t *testing.T) {
+	usePolicy(t, slowClientResync)
+	c, peer := newTestClient(t)
+
+	// One more than the queue holds while nothing is written
+	for i := range sendQueueSize + 1 {
+		c.write("test", i)
+	}
+	go c.writeLoop()
+	c.write("test", "after")
+
+	evt, err := readEvent(t, peer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lagged struct {
+		Dropped int `json:"dropped"`
+	}
+	_ = json.Unmarshal(evt.Data, &lagged)
+	if evt.Type != "lagged" || lagged.Dropped != sendQueueSize+1 || evt.Seq != sendQueueSize+2 {
+		t.Fatalf("got %s %s with seq %d, want lagged after %d dropped events", evt.Type, evt.Data, evt.Seq, sendQueueSize+1)
+	}
+	evt, err = readEvent(t, peer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if evt.Seq != sendQueueSize+3 || string(evt.Data) != `"after"` {
+		t.Errorf("got %s with seq %d after lagged", evt.Data, evt.Seq)
+	}
+}
+
+func TestFullQueueDisconnects(t *testing.T) {
+	usePolicy(t, slowClientDisconnect)
+	c, peer := newTestClient(t)
+
+	for i := range sendQueueSize + 1 {
+		c.write("test", i)
+	}
+	_, err := readEvent(t, peer)
+	if status := websocket.CloseStatus(err); status != websocket.StatusPolicyViolation {
+		t.Errorf("read %v, want a close with status %d", err, websocket.StatusPolicyViolation)
+	}
+
+	// Nothing more is queued once the client is dropped
+	queued := len(c.queue)
+	c.write("test", "after")
+	if len(c.queue) != queued {
+		t.Error("event queued after the disconnection")
+	}
+}
+
+func TestCloseAfterFlush(t *testing.T) {
+	c, peer := newTestClient(t)
+
+	const events = 10
+	for i := range events {
+		c.write("test", i)
+	}
+	c.closeAfterFlush(websocket.StatusGoingAway, "bye")
+	c.write("test", "discarded")
+	go c.writeLoop()
+
+	for want := uint64(1); want <= events; want++ {
+		evt, err := readEvent(t, peer)
+		if err != nil {
+			t.Fatalf("event %d: %v", want, err)
+		}
+		if evt.Seq != want {
+			t.Fatalf("seq %d, want %d", evt.Seq, want)
+		}
+	}
+	_, err := readEvent(t, peer)
+	if status := websocket.CloseStatus(err); status != websocket.StatusGoingAway {
+		t.Errorf("read %v, want a close with status %d", err, websocket.StatusGoingAway)
+	}
+}
@@ -0,0 +1,20 @@
+package routers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"omgtant/claustroboard/shared/models"
+)
+
+// GetReplay returns the archive of the last game finished under a code.
+func GetReplay(w http.ResponseWriter, r *http.Request) {
+	replay, err := models.GetReplay(models.GameCode(r.PathValue("code")))
+	if err != nil {
+		httpError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(replay)
+}
@@ -0,0 +1,17 @@
+package routers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"omgtant/claustroboard/shared/models"
+)
+
+// Stats returns the number of live and evicted boards, and of connected sockets.
+func Stats(w http.ResponseWriter, r *http.Request) {
+	stats := models.BoardStats()
+	stats.Clients = LiveClients()
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(stats)
+}
@@ -0,0 +1,83 @@
+package routers
+
+import (
+	"time"
+
+	"omgtant/claustroboard/shared/dtos"
+	"omgtant/claustroboard/shared/models"
+)
+
+var turnTimers = make(map[models.GameCode]*time.Timer) // Guarded by mu
+
+// onTurnChanged is called after anything that may hand the turn to someone else:
+// it restarts the turn timer and lets bots play, without blocking the caller.
+func onTurnChanged(gameCode models.GameCode) {
+	go func() {
+		scheduleTurnTimer(gameCode)
+		runBots(gameCode)
+	}()
+}
+
+// scheduleTurnTimer replaces the timer of a game with one for the current turn
+// and tells clients when it runs out. Games without a time limit get no timer.
+func scheduleTurnTimer(gameCode models.GameCode) {
+	stopTurnTimer(gameCode)
+
+	clock, err := models.CurrentTurnClock(gameCode)
+	if err != nil || clock == nil {
+		return
+	}
+
+	mu.Lock()
+	turnTimers[gameCode] = time.AfterFunc(time.Until(clock.Deadline), func() {
+		timeoutTurn(gameCode, clock.Turn)
+	})
+	mu.Unlock()
+
+	broadcastEvent(gameCode, event{
+		Type: "turn-timer",
+		Data: clock,
+	})
+}
+
+func stopTurnTimer(gameCode models.GameCode) {
+	mu.Lock()
+	defer mu.Unlock()
+	if t, ok := turnTimers[gameCode]; ok {
+		t.Stop()
+		delete(turnTimers, gameCode)
+	}
+}
+
+// timeoutTurn ends a turn the current player ran out of time on.
+func timeoutTurn(gameCode models.GameCode, turn uint32) {
+	out, err := models.TimeoutTurn(gameCode, turn)
+	if err != nil {
+		// The player moved just in time
+		return
+	}
+
+	if out.Delta.Timeout == dtos.TimeoutRandom {
+		broadcastEvent(gameCode, event{
+			Type: "they-moved",
+			Data: out.Delta,
+		})
+	} else {
+		broadcastEvent(gameCode, event{
+			Type: "turn-timeout",
+			Data: out.Delta,
+		})
+	}
+	if out.Result != nil {
+		broadcastGameOver(gameCode, out.Result)
+		return
+	}
+	onTurnChanged(gameCode)
+}
+
+// ResumeGames restarts the turn timers and bots of the games restored from storage.
+func ResumeGames() {
+	for _, code := range models.StartedGames() {
+		onTurnChanged(code)
+	}
+}
@@ -3,24 +3,37 @@ package routers
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"runtime/debug"
 	"sync"
 	"time"
 
 	"omgtant/claustroboard/shared/models"
 
 	"github.com/coder/websocket"
-	"github.com/coder/websocket/wsjson"
 )
 
 type wsClient struct {
-	conn     *websocket.Conn
-	gameCode models.GameCode
-	nickname string
+	conn      *websocket.Conn
+	gameCode  models.GameCode
+	nickname  string
+	spectator bool // Receives broadcasts but cannot act
+
+	// Bounds the goroutines of the connection, cancelled once it is cleaned up
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	queue   chan outbound // Drained by writeLoop
+	sendMu  sync.Mutex
+	seq     uint64 // Of the last event sent to the client
+	closing bool   // Set once nothing more should be sent
 }
 
 type event struct {
 	Type string `json:"type"`
+	Seq  uint64 `json:"seq,omitempty"` // Numbers the events of a connection from 1, without gaps
 	Data any    `json:"data,omitempty"`
 }
 
@@ -29,26 +42,58 @@ type inboundEvent struct {
 	Data json.RawMessage `json:"data,omitempty"`
 }
 
+type seat struct {
+	gameCode models.GameCode
+	nickname string
+}
+
+// How long a disconnected player's seat is held for them to rejoin.
+const reconnectGracePeriod = 30 * time.Second
+
 var (
-	mu          sync.Mutex
-	gameClients = make(map[models.GameCode]map[*wsClient]struct{})
+	mu            sync.Mutex
+	gameClients   = make(map[models.GameCode]map[*wsClient]struct{})
+	pendingLeaves = make(map[seat]*time.Timer)
+
+	// Every live connection, including those no longer attached to a game
+	liveClients = make(map[*wsClient]struct{})
+	clientsDone sync.WaitGroup // One per live connection
+	draining    bool           // Set by Shutdown, no connection is accepted afterwards
+
+	// Parent of the contexts of every connection
+	clientsCtx, cancelClients = context.WithCancel(context.Background())
 )
 
-func upgradeAndRegister(w http.ResponseWriter, r *http.Request, gameCode models.GameCode, nickname string) (*wsClient, error) {
+var errShuttingDown = errors.New("server is shutting down")
+
+func upgradeAndRegister(w http.ResponseWriter, r *http.Request, gameCode models.GameCode, nickname string, spectator bool) (*wsClient, error) {
+	mu.Lock()
+	if draining {
+		mu.Unlock()
+		return nil, errShuttingDown
+	}
+	clientsDone.Add(1)
+	mu.Unlock()
+
 	c, err := websocket.Accept(w, r, &websocket.AcceptOptions{
 		OriginPatterns: []string{"*"},
 	})
 	if err != nil {
+		clientsDone.Done()
 		return nil, err
 	}
 
 	client := &wsClient{
-		conn:     c,
-		gameCode: gameCode,
-		nickname: nickname,
+		conn:      c,
+		gameCode:  gameCode,
+		nickname:  nickname,
+		spectator: spectator,
+		queue:     make(chan outbound, sendQueueSize),
 	}
+	client.ctx, client.cancel = context.WithCancel(clientsCtx)
 
 	mu.Lock()
+	liveClients[client] = struct{}{}
 	if gameClients[gameCode] == nil {
 		gameClients[gameCode] = make(map[*wsClient]struct{})
 	}
@@ -56,6 +101,7 @@ func upgradeAndRegister(w http.ResponseWriter, r *http.Request, gameCode models.
 	mu.Unlock()
 
 	go client.readLoop()
+	go client.writeLoop()
 	go client.writePing()
 
 	return client, nil
@@ -64,7 +110,7 @@ func upgradeAndRegister(w http.ResponseWriter, r *http.Request, gameCode models.
 func (c *wsClient) readLoop() {
 	defer c.closeAndCleanup()
 	for {
-		typ, data, err := c.conn.Read(context.Background())
+		typ, data, err := c.conn.Read(c.ctx)
 		if err != nil {
 			return
 		}
@@ -75,63 +121,229 @@ func (c *wsClient) readLoop() {
 		if err := json.Unmarshal(data, &ie); err != nil {
 			continue
 		}
-		if h, ok := inboundHandlers[ie.Type]; ok {
-			h(c, ie.Data)
+		h, ok := inboundHandlers[ie.Type]
+		if !ok {
+			continue
 		}
+		if c.spectator && !spectatorActions[ie.Type] {
+			c.writeError(fmt.Errorf("%w: spectators cannot %s", models.ErrNotAllowed, ie.Type))
+			continue
+		}
+		c.handle(h, ie)
 	}
 }
 
-func (c *wsClient) write(t string, data any) {
-	_ = wsjson.Write(context.Background(), c.conn, event{t, data})
-}
-
-func (c *wsClient) writeError(err error) {
-	_ = wsjson.Write(context.Background(), c.conn, event{
-		Type: "error",
-		Data: err.Error(),
-	})
-}
-
-func (c *wsClient) writePing() {
-	t := time.NewTicker(30 * time.Second)
-	defer t.Stop()
-	for {
-		select {
-		case <-t.C:
-			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
-			_ = c.conn.Ping(ctx)
-			cancel()
+// handle runs the handler of an inbound event. A handler that panics only fails
+// its action, the connection and the rest of the server carry on.
+func (c *wsClient) handle(h func(*wsClient, json.RawMessage), ie inboundEvent) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Printf("Action %s in game %s panicked: %v\n%s", ie.Type, c.gameCode, r, debug.Stack())
+			c.writeError(fmt.Errorf("%s failed", ie.Type))
 		}
-	}
+	}()
+	h(c, ie.Data)
 }
 
 func (c *wsClient) closeAndCleanup() {
+	c.sendMu.Lock()
+	c.closing = true
+	c.sendMu.Unlock()
+	c.cancel()
 	c.conn.Close(websocket.StatusNormalClosure, "")
+	defer clientsDone.Done()
+
 	mu.Lock()
+	delete(liveClients, c)
 	clients := gameClients[c.gameCode]
 	if clients != nil {
 		if _, ok := clients[c]; ok {
 			delete(clients, c)
-			_ = models.Leave(c.gameCode, c.nickname)
+			if !c.spectator {
+				holdSeat(seat{c.gameCode, c.nickname})
+			}
 		}
 		if len(clients) == 0 {
 			delete(gameClients, c.gameCode)
 		}
 	}
 	mu.Unlock()
-	broadcastPlayerList(c.gameCode)
+	if c.spectator {
+		broadcastPlayerList(c.gameCode)
+	}
+}
+
+// holdSeat keeps a disconnected player's seat for the grace period,
+// after which they leave the game. Must be called with mu held.
+// Seats of players disconnected by Shutdown are kept until they rejoin.
+func holdSeat(s seat) {
+	if draining {
+		return
+	}
+	if t, ok := pendingLeaves[s]; ok {
+		t.Stop()
+	}
+	var t *time.Timer
+	t = time.AfterFunc(reconnectGracePeriod, func() {
+		mu.Lock()
+		if pendingLeaves[s] != t {
+			// Rejoined in the meantime
+			mu.Unlock()
+			return
+		}
+		delete(pendingLeaves, s)
+		mu.Unlock()
+
+		leaveSeat(s)
+	})
+	pendingLeaves[s] = t
+}
+
+// reclaimSeat cancels a pending leave and detaches every other socket of the seat.
+// Must be called with mu held.
+func reclaimSeat(s seat) {
+	if t, ok := pendingLeaves[s]; ok {
+		t.Stop()
+		delete(pendingLeaves, s)
+	}
+	for cl := range gameClients[s.gameCode] {
+		if cl.nickname == s.nickname && !cl.spectator {
+			delete(gameClients[s.gameCode], cl)
+			go cl.conn.Close(websocket.StatusPolicyViolation, "reconnected elsewhere")
+		}
+	}
+}
+
+func leaveSeat(s seat) {
+	departure, err := models.Leave(s.gameCode, s.nickname)
+	if err != nil {
+		return
+	}
+	broadcastPlayerList(s.gameCode)
+	if departure.WasHost {
+		broadcastHost(s.gameCode)
+	}
+	broadcastForfeit(s.gameCode, departure)
 }
 
+// broadcastForfeit tells clients that a player left a game in progress,
+// which is a turn of its own and may end the game.
+func broadcastForfeit(gameCode models.GameCode, departure models.Departure) {
+	if departure.Delta != nil {
+		broadcastEvent(gameCode, event{
+			Type: "they-moved",
+			Data: departure.Delta,
+		})
+	}
+	if departure.Result != nil {
+		broadcastGameOver(gameCode, departure.Result)
+	}
+	if departure.WasStarted {
+		onTurnChanged(gameCode)
+	}
+}
+
+// broadcastEvent queues evt for every client of the game, each numbering it in its own sequence.
 func broadcastEvent(gameCode models.GameCode, evt event) {
-	payload, _ := json.Marshal(evt)
+	if evt.Data != nil {
+		data, err := json.Marshal(evt.Data)
+		if err != nil {
+			return
+		}
+		evt.Data = json.RawMessage(data)
+	}
 
 	mu.Lock()
 	defer mu.Unlock()
 	for c := range gameClients[gameCode] {
-		go func(cl *wsClient, msg []byte) {
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer cancel()
-			_ = cl.conn.Write(ctx, websocket.MessageText, msg)
-		}(c, payload)
+		c.send(evt)
+	}
+}
+
+// GameCrashed tells the clients of a game that it stopped after an internal error.
+// It is set as the crash handler of models.
+func GameCrashed(gameCode models.GameCode, err error) {
+	stopTurnTimer(gameCode)
+	broadcastEvent(gameCode, event{
+		Type: "game-error",
+		Data: newErrorPayload(err),
+	})
+}
+
+// HasClients reports whether any socket is connected to the game.
+func HasClients(gameCode models.GameCode) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return len(gameClients[gameCode]) > 0
+}
+
+// CloseGame disconnects every client of a game that no longer exists.
+func CloseGame(gameCode models.GameCode) {
+	mu.Lock()
+	clients := gameClients[gameCode]
+	delete(gameClients, gameCode)
+	for s, t := range pendingLeaves {
+		if s.gameCode == gameCode {
+			t.Stop()
+			delete(pendingLeaves, s)
+		}
+	}
+	if t, ok := turnTimers[gameCode]; ok {
+		t.Stop()
+		delete(turnTimers, gameCode)
+	}
+	mu.Unlock()
+
+	for c := range clients {
+		go c.conn.Close(websocket.StatusGoingAway, "game closed")
+	}
+}
+
+func countSpectators(gameCode models.GameCode) (n int) {
+	mu.Lock()
+	defer mu.Unlock()
+	for c := range gameClients[gameCode] {
+		if c.spectator {
+			n++
+		}
+	}
+	return n
+}
+
+// LiveClients counts the open sockets.
+func LiveClients() int {
+	mu.Lock()
+	defer mu.Unlock()
+	return len(liveClients)
+}
+
+// Shutdown refuses new sockets, sends `server-restarting` to every client and
+// closes their sockets once it is sent. Sockets still open when ctx is done are dropped.
+func Shutdown(ctx context.Context) {
+	mu.Lock()
+	draining = true
+	for code, t := range turnTimers {
+		t.Stop()
+		delete(turnTimers, code)
+	}
+	for s, t := range pendingLeaves {
+		t.Stop()
+		delete(pendingLeaves, s)
+	}
+	for c := range liveClients {
+		c.write("server-restarting", nil)
+		c.closeAfterFlush(websocket.StatusServiceRestart, "server restarting")
+	}
+	mu.Unlock()
+
+	closed := make(chan struct{})
+	go func() {
+		clientsDone.Wait()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-ctx.Done():
 	}
+	cancelClients()
 }
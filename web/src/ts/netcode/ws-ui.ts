@@ -144,7 +144,7 @@ function initPrepState() {
     });
 }
 
-netcode.ws.on('playerlist-changed', (currentPlayers) => {
+netcode.ws.on('playerlist-changed', ({players: currentPlayers}) => {
     console.log('Player list changed:', currentPlayers);
     const playerDelta = currentPlayers.filter(nick => !netcode.players.includes(nick));
     if (playerDelta.length > 0) {
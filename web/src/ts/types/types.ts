@@ -57,13 +57,25 @@ export type ValidMove = {
 }
 
 export interface EventMap {
-    'created': {code: string}
-    'playerlist-changed': string[],
+    'created': {code: string, token: string},
+    'joined': {code: string, you: string, token: string, host: string},
+    'rejoined': {code: string, you: string, host: string, snapshot?: InitialState},
+    'watching': {code: string, host: string, snapshot: InitialState, deltas: MoveDelta[]},
+    'host-changed': {host: string},
+    'kicked': null,
+    'playerlist-changed': {players: string[], bots: string[], spectators: number},
     'start': void,
     'started': InitialState,
     'my-move': MoveDelta,
     'they-moved': MoveDelta,
-    'come-again': MoveDelta,
+    'turn-timer': {turn: number, player: string, deadline: string},
+    'turn-timeout': {turn: number, timeout: TimeoutPolicy},
+    'come-again': Resync,
+    'game-over': {placements: {place: number, nickname: string}[]},
+    'lagged': {dropped: number},
+    'server-restarting': void,
+    'game-error': {code: string, message: string},
+    'state-hash': {turn: number, hash: string},
     'close': void,
     'broadcast': any,
     'error': any
@@ -78,9 +90,23 @@ export type Netcode = {
 
 export type MoveDelta = {
     turn: number,
-    move: Pos
+    move: Pos,
+    timeout?: TimeoutPolicy,
+    forfeit?: number,
+    hash?: string
 }
 
+/**
+ * Reply to `come-again`: the deltas after the requested turn, or the current state
+ */
+export type Resync = {
+    turn: number,
+    deltas?: MoveDelta[],
+    snapshot?: InitialState
+}
+
+export type TimeoutPolicy = 'random' | 'skip' | 'eliminate';
+
 export type DeckElement = {
     tile: TileSetup,
     count?: number
@@ -91,5 +117,7 @@ export type Config = {
     width: number;
     height: number;
     maxPlayers: number;
+    turnTimeLimit?: number;
+    timeoutPolicy?: TimeoutPolicy;
     deck: DeckElement[]
 }
\ No newline at end of file